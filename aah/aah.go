@@ -126,6 +126,8 @@ func main() {
 		cleanCmd,
 		generateCmd,
 		migrateCmd,
+		verifyCmd,
+		vfsCmd,
 	}
 
 	// Global flags
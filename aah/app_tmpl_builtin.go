@@ -0,0 +1,769 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"aahframe.work/config"
+	"aahframe.work/essentials"
+)
+
+// Built-in app template files are shipped with CLI for the features
+// app-templates repo does not carry yet. Its processed same as the template
+// files, source name is prefixed with 'builtin:'.
+const builtinTmplPrefix = "builtin:"
+
+func builtinTmplFile(name, appBaseDir, content string) file {
+	return file{
+		src:     builtinTmplPrefix + name,
+		dst:     filepath.Join(appBaseDir, filepath.FromSlash(name)),
+		content: content,
+	}
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// OAuth2 auth scheme
+//___________________________________
+
+func oauth2TmplFiles(appBaseDir string) []file {
+	return []file{
+		builtinTmplFile("config/security.conf"+aahTmplExt, appBaseDir, oauth2SecurityConfTemplate),
+		builtinTmplFile("app/security/oauth2_principal_provider.go"+aahTmplExt, appBaseDir, oauth2PrincipalTemplate),
+		builtinTmplFile("app/security/oauth2_client.go"+aahTmplExt, appBaseDir, oauth2ClientTemplate),
+	}
+}
+
+const oauth2SecurityConfTemplate = `# -----------------------------------------------------------------------------
+# {{ .App.Name }} - Application Security Configuration
+#
+# Refer documentation to explore and customize the configurations.
+# Doc: https://docs.aahframework.org/security-config.html
+# -----------------------------------------------------------------------------
+
+security {
+  # -----------------------------------------------------------------------------
+  # Auth Schemes configuration
+  # Doc: https://docs.aahframework.org/authentication.html
+  # -----------------------------------------------------------------------------
+  auth_schemes {
+    # OAuth2 auth scheme
+    # Doc: https://docs.aahframework.org/auth-schemes/oauth2.html
+    {{ .App.OAuth2SchemeName }} {
+      scheme = "oauth2"
+
+      # Principal provider maps the provider user info to application subject.
+      principal = "security/OAuth2PrincipalProvider"
+
+      client {
+        # Client id and secret are populated from environment variables
+        # '{{ .App.OAuth2ClientIDEnv }}' and '{{ .App.OAuth2ClientSecretEnv }}' at application
+        # init, refer to 'app/security/oauth2_client.go'. Do not commit secrets.
+        id = ""
+        secret = ""
+
+        provider {
+          name = "{{ .App.OAuth2Provider }}"
+          url {
+            auth = "{{ .App.OAuth2AuthURL }}"
+            token = "{{ .App.OAuth2TokenURL }}"
+          }
+        }
+      }
+
+      # OAuth2 scopes requested from the provider
+      scopes = [{{ .App.OAuth2Scopes }}]
+
+      url {
+        # Login URL initiates the OAuth2 authorization flow
+        login = "/auth/{{ .App.OAuth2Provider }}/login"
+
+        # Callback URL registered with the provider
+        callback = "{{ .App.OAuth2CallbackPath }}"
+      }
+    }
+  }
+
+  # -----------------------------------------------------------------------------
+  # Session configuration
+  # Doc: https://docs.aahframework.org/security-config.html#section-session
+  # -----------------------------------------------------------------------------
+  session {
+    mode = "stateful"
+
+    store {
+      type = "{{ .App.SessionStore }}"{{ if eq .App.SessionStore "file" }}
+      filepath = "{{ .App.SessionFileStorePath }}"{{ end }}
+    }
+
+    prefix = "{{ .App.DomainNameKey }}"
+    sign_key = "{{ securerandomstring 64 }}"
+    enc_key = "{{ securerandomstring 32 }}"
+  }
+
+  # -----------------------------------------------------------------------------
+  # Anti-CSRF configuration
+  # Doc: https://docs.aahframework.org/security-config.html#section-anti-csrf
+  # -----------------------------------------------------------------------------
+  anti_csrf {
+    enable = true
+    sign_key = "{{ securerandomstring 64 }}"
+    enc_key = "{{ securerandomstring 32 }}"
+  }
+}
+`
+
+const oauth2PrincipalTemplate = `package security
+
+import (
+	"aahframe.work/config"
+	"aahframe.work/essentials"
+	"aahframe.work/security/authc"
+)
+
+var _ authc.PrincipalProvider = (*OAuth2PrincipalProvider)(nil)
+
+// OAuth2PrincipalProvider struct implements 'authc.PrincipalProvider' for
+// the OAuth2 auth scheme '{{ .App.OAuth2SchemeName }}' ({{ .App.OAuth2Provider }}).
+type OAuth2PrincipalProvider struct {
+}
+
+// Init method initializes the OAuth2PrincipalProvider, this method gets called
+// during server start up.
+func (p *OAuth2PrincipalProvider) Init(appCfg *config.Config) error {
+	// NOTE: Init is called on application startup
+	return nil
+}
+
+// Principal method called by aah after successful OAuth2 authorization flow
+// to obtain the principals of the subject.
+func (p *OAuth2PrincipalProvider) Principal(keyName string, v ess.Valuer) ([]*authc.Principal, error) {
+	// TODO: Fetch the user info from '{{ .App.OAuth2Provider }}' using the access token
+	// available in 'v' and map it to your application principals.
+	principals := make([]*authc.Principal, 0)
+	principals = append(principals, &authc.Principal{
+		Realm:     "{{ .App.OAuth2Provider }}",
+		Claim:     "Provider",
+		Value:     "{{ .App.OAuth2Provider }}",
+		IsPrimary: true,
+	})
+	return principals, nil
+}
+`
+
+const oauth2ClientTemplate = `package security
+
+import (
+	"os"
+
+	"aahframe.work"
+)
+
+func init() {
+	// OAuth2 client credentials are supplied via environment variables and
+	// populated into application config before security gets initialized.
+	aah.App().OnInit(func(_ *aah.Event) {
+		app := aah.App()
+		keyPrefix := "security.auth_schemes.{{ .App.OAuth2SchemeName }}.client."
+		for key, env := range map[string]string{
+			"id":     "{{ .App.OAuth2ClientIDEnv }}",
+			"secret": "{{ .App.OAuth2ClientSecretEnv }}",
+		} {
+			v := os.Getenv(env)
+			if len(v) == 0 {
+				app.Log().Warnf("OAuth2: environment variable '%s' is not set", env)
+				continue
+			}
+			app.Config().SetString(keyPrefix+key, v)
+		}
+	})
+}
+`
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// JWT auth scheme
+//___________________________________
+
+func jwtTmplFiles(appBaseDir string) []file {
+	return []file{
+		builtinTmplFile("config/security.conf"+aahTmplExt, appBaseDir, jwtSecurityConfTemplate),
+		builtinTmplFile("app/security/jwt.go"+aahTmplExt, appBaseDir, jwtTokenTemplate),
+		builtinTmplFile("app/security/jwt_authenticator.go"+aahTmplExt, appBaseDir, jwtAuthenticatorTemplate),
+		builtinTmplFile("app/security/jwt_principal_provider.go"+aahTmplExt, appBaseDir, jwtPrincipalTemplate),
+		builtinTmplFile("app/security/jwt_users.go"+aahTmplExt, appBaseDir, jwtUsersTemplate),
+		builtinTmplFile("app/models/token.go"+aahTmplExt, appBaseDir, jwtModelsTemplate),
+		builtinTmplFile("app/controllers/v1/token.go"+aahTmplExt, appBaseDir, jwtControllerTemplate),
+	}
+}
+
+// jwtTokenRoute is the token route of JWT auth scheme, it's added into
+// application routes configuration.
+var jwtTokenRoute = &routeEntry{
+	Key:        "token",
+	Path:       "/v1/token",
+	Method:     "POST",
+	Controller: "v1/TokenController",
+	Action:     "Token",
+	Auth:       "anonymous",
+}
+
+// wireJWTRoute method adds the token route into first domain of
+// 'config/routes.conf'.
+func wireJWTRoute(appBaseDir string) error {
+	routesFile := filepath.Join(appBaseDir, "config", "routes.conf")
+	src, err := ioutil.ReadFile(routesFile)
+	if err != nil {
+		return err
+	}
+	result, _, err := addRoutes(src, "", []*routeEntry{jwtTokenRoute})
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(routesFile, result, permRWRWRW)
+}
+
+const jwtSecurityConfTemplate = `# -----------------------------------------------------------------------------
+# {{ .App.Name }} - Application Security Configuration
+#
+# Refer documentation to explore and customize the configurations.
+# Doc: https://docs.aahframework.org/security-config.html
+# -----------------------------------------------------------------------------
+
+security {
+  # -----------------------------------------------------------------------------
+  # Auth Schemes configuration
+  # Doc: https://docs.aahframework.org/authentication.html
+  # -----------------------------------------------------------------------------
+  auth_schemes {
+    # JWT bearer token auth scheme, built on top of generic auth scheme.
+    # Doc: https://docs.aahframework.org/auth-schemes/generic.html
+    jwt_auth {
+      scheme = "generic"
+
+      # Authenticator validates the bearer token from request header.
+      authenticator = "security/JWTAuthenticator"
+
+      # Principal provider supplies additional principals of the subject.
+      principal = "security/JWTPrincipalProvider"
+
+      header {
+        # Bearer token is read from 'Authorization' header.
+        identity = "Authorization"
+      }
+
+      jwt {
+        # HMAC SHA-256 signing key. Environment variable 'JWT_SIGNING_KEY'
+        # overrides this value, use it for production.
+        signing_key = "{{ securerandomstring 64 }}"
+
+        # Token issuer, verified on every request.
+        issuer = "{{ .App.Name }}"
+
+        # Token validity duration.
+        expires_in = "1h"
+      }
+    }
+  }
+}
+`
+
+const jwtTokenTemplate = `package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"aahframe.work/config"
+)
+
+const jwtKeyPrefix = "security.auth_schemes.jwt_auth.jwt."
+
+var (
+	// ErrTokenInvalid returned when token is malformed or signature mismatch.
+	ErrTokenInvalid = errors.New("security: invalid token")
+
+	// ErrTokenExpired returned when token is expired.
+	ErrTokenExpired = errors.New("security: token expired")
+
+	jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(` + "`" + `{"alg":"HS256","typ":"JWT"}` + "`" + `))
+
+	jwtSigningKey []byte
+	jwtIssuer     string
+	jwtExpiresIn  time.Duration
+)
+
+// Claims struct holds the JWT claims issued by the application.
+type Claims struct {
+	Subject   string ` + "`" + `json:"sub"` + "`" + `
+	Issuer    string ` + "`" + `json:"iss"` + "`" + `
+	IssuedAt  int64  ` + "`" + `json:"iat"` + "`" + `
+	ExpiresAt int64  ` + "`" + `json:"exp"` + "`" + `
+}
+
+// IssueToken method creates signed JWT token for the given subject.
+func IssueToken(subject string) (string, error) {
+	now := time.Now().UTC()
+	b, err := json.Marshal(&Claims{
+		Subject:   subject,
+		Issuer:    jwtIssuer,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(jwtExpiresIn).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	payload := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(b)
+	return payload + "." + jwtSign(payload), nil
+}
+
+// ParseToken method verifies the token signature, issuer and expiry then
+// returns its claims.
+func ParseToken(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return nil, ErrTokenInvalid
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(jwtSign(parts[0]+"."+parts[1]))) {
+		return nil, ErrTokenInvalid
+	}
+	b, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrTokenInvalid
+	}
+	claims := &Claims{}
+	if err = json.Unmarshal(b, claims); err != nil {
+		return nil, ErrTokenInvalid
+	}
+	if claims.Issuer != jwtIssuer {
+		return nil, ErrTokenInvalid
+	}
+	if time.Now().UTC().Unix() > claims.ExpiresAt {
+		return nil, ErrTokenExpired
+	}
+	return claims, nil
+}
+
+// TokenExpiresIn method returns token validity in seconds.
+func TokenExpiresIn() int64 {
+	return int64(jwtExpiresIn / time.Second)
+}
+
+func initJWT(appCfg *config.Config) error {
+	signingKey := os.Getenv("JWT_SIGNING_KEY")
+	if len(signingKey) == 0 {
+		signingKey = appCfg.StringDefault(jwtKeyPrefix+"signing_key", "")
+	}
+	if len(signingKey) == 0 {
+		return errors.New("security: JWT signing key is not configured")
+	}
+
+	expiresIn, err := time.ParseDuration(appCfg.StringDefault(jwtKeyPrefix+"expires_in", "1h"))
+	if err != nil {
+		return err
+	}
+
+	jwtSigningKey = []byte(signingKey)
+	jwtIssuer = appCfg.StringDefault(jwtKeyPrefix+"issuer", "{{ .App.Name }}")
+	jwtExpiresIn = expiresIn
+	return nil
+}
+
+func jwtSign(payload string) string {
+	mac := hmac.New(sha256.New, jwtSigningKey)
+	_, _ = mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+`
+
+const jwtAuthenticatorTemplate = `package security
+
+import (
+	"strings"
+
+	"aahframe.work"
+	"aahframe.work/config"
+	"aahframe.work/security/authc"
+)
+
+var _ authc.Authenticator = (*JWTAuthenticator)(nil)
+
+// JWTAuthenticator struct implements 'authc.Authenticator' for the
+// JWT bearer token auth scheme 'jwt_auth'.
+type JWTAuthenticator struct {
+}
+
+// Init method initializes the JWTAuthenticator, this method gets called
+// during server start up.
+func (a *JWTAuthenticator) Init(appCfg *config.Config) error {
+	return initJWT(appCfg)
+}
+
+// GetAuthenticationInfo method validates the bearer token supplied via
+// 'Authorization' header and returns the authentication info of the subject.
+func (a *JWTAuthenticator) GetAuthenticationInfo(authcToken *authc.AuthenticationToken) (*authc.AuthenticationInfo, error) {
+	token := strings.TrimSpace(strings.TrimPrefix(authcToken.Identity, "Bearer"))
+	claims, err := ParseToken(token)
+	if err != nil {
+		aah.App().Log().Debugf("JWT: %v", err)
+		return nil, authc.ErrAuthenticationFailed
+	}
+
+	authcInfo := authc.NewAuthenticationInfo()
+	authcInfo.Principals = append(authcInfo.Principals, &authc.Principal{
+		Realm:     "jwt",
+		Claim:     "Subject",
+		Value:     claims.Subject,
+		IsPrimary: true,
+	})
+	return authcInfo, nil
+}
+`
+
+const jwtPrincipalTemplate = `package security
+
+import (
+	"aahframe.work/config"
+	"aahframe.work/essentials"
+	"aahframe.work/security/authc"
+)
+
+var _ authc.PrincipalProvider = (*JWTPrincipalProvider)(nil)
+
+// JWTPrincipalProvider struct implements 'authc.PrincipalProvider' for
+// the JWT bearer token auth scheme 'jwt_auth'.
+type JWTPrincipalProvider struct {
+}
+
+// Init method initializes the JWTPrincipalProvider, this method gets called
+// during server start up.
+func (p *JWTPrincipalProvider) Init(appCfg *config.Config) error {
+	// NOTE: Init is called on application startup
+	return nil
+}
+
+// Principal method called by aah to obtain additional principals of
+// the subject, the primary principal is supplied by JWTAuthenticator.
+func (p *JWTPrincipalProvider) Principal(keyName string, v ess.Valuer) ([]*authc.Principal, error) {
+	// TODO: Load additional principals of the subject from your data store.
+	return make([]*authc.Principal, 0), nil
+}
+`
+
+const jwtUsersTemplate = `package security
+
+import "crypto/subtle"
+
+// sampleUsers is the in-memory credential store used by the sample token
+// controller.
+//
+// TODO: Replace it with your user store and password hashing.
+var sampleUsers = map[string]string{
+	"user1": "welcome123",
+}
+
+// ValidateCredentials method returns true if username and password matches.
+func ValidateCredentials(username, password string) bool {
+	expected, found := sampleUsers[username]
+	if !found {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(password)) == 1
+}
+`
+
+const jwtModelsTemplate = `package models
+
+// TokenRequest struct holds the client credentials to obtain access token.
+type TokenRequest struct {
+	Username string ` + "`" + `json:"username"` + "`" + `
+	Password string ` + "`" + `json:"password"` + "`" + `
+}
+
+// TokenResponse struct is the access token reply.
+type TokenResponse struct {
+	AccessToken string ` + "`" + `json:"access_token"` + "`" + `
+	TokenType   string ` + "`" + `json:"token_type"` + "`" + `
+	ExpiresIn   int64  ` + "`" + `json:"expires_in"` + "`" + `
+}
+`
+
+const jwtControllerTemplate = `package v1
+
+import (
+	"aahframe.work"
+
+	"{{ .App.ImportPath }}/app/models"
+	"{{ .App.ImportPath }}/app/security"
+)
+
+// TokenController issues JWT bearer tokens for the API clients.
+type TokenController struct {
+	*aah.Context
+}
+
+// Token method validates the client credentials and replies signed
+// access token.
+func (c *TokenController) Token(req *models.TokenRequest) {
+	if !security.ValidateCredentials(req.Username, req.Password) {
+		c.Reply().Unauthorized().JSON(aah.Data{
+			"message": "invalid credentials",
+		})
+		return
+	}
+
+	token, err := security.IssueToken(req.Username)
+	if err != nil {
+		c.Log().Error(err)
+		c.Reply().InternalServerError().JSON(aah.Data{
+			"message": "unable to issue token",
+		})
+		return
+	}
+
+	c.Reply().Ok().JSON(&models.TokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   security.TokenExpiresIn(),
+	})
+}
+`
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Database layer
+//___________________________________
+
+func dbTmplFiles(appBaseDir string) []file {
+	return []file{
+		builtinTmplFile("config/db.conf"+aahTmplExt, appBaseDir, dbConfTemplate),
+		builtinTmplFile("app/db/db.go"+aahTmplExt, appBaseDir, dbTemplate),
+		builtinTmplFile("app/db/migrate.go"+aahTmplExt, appBaseDir, dbMigrateTemplate),
+		builtinTmplFile("db/migrations/0001_init.sql", appBaseDir, dbInitMigration),
+	}
+}
+
+// wireDatabase method includes 'db.conf' into 'aah.conf' and registers the
+// database connection lifecycle into 'app/init.go' of rendered application.
+// Migrations are read from app VFS mount, so 'db' directory gets embedded
+// into single binary build unless 'build.excludes' skips it.
+func wireDatabase(app *appTmplData, appBaseDir string) error {
+	if err := checkDBMount(appBaseDir); err != nil {
+		return err
+	}
+	if err := addConfInclude(filepath.Join(appBaseDir, "config", "aah.conf"), "db.conf",
+		"Database configuration\nDoc: https://golang.org/pkg/database/sql"); err != nil {
+		return err
+	}
+	return addInitFuncStmts(filepath.Join(appBaseDir, "app", "init.go"), app.ImportPath+"/app/db",
+		"// Database connection lifecycle, refer to 'app/db/db.go'",
+		"aah.App().OnStart(db.Connect)",
+		"aah.App().OnPostShutdown(db.Close)",
+	)
+}
+
+// checkDBMount method verifies the 'build.excludes' of 'aah.project' does
+// not skip migrations from app VFS mount.
+func checkDBMount(appBaseDir string) error {
+	projectFile := filepath.Join(appBaseDir, aahProjectIdentifier)
+	if !ess.IsFileExists(projectFile) {
+		return nil
+	}
+	projectCfg, err := config.LoadFile(projectFile)
+	if err != nil {
+		return err
+	}
+	cfgExcludes, _ := projectCfg.StringList("build.excludes")
+	excludes := ess.Excludes(cfgExcludes)
+	for _, name := range []string{"db", "migrations", "0001_init.sql"} {
+		if excludes.Match(name) {
+			return fmt.Errorf("'build.excludes' of '%s' skips '%s', migrations would not be part of app build", aahProjectIdentifier, name)
+		}
+	}
+	return nil
+}
+
+const dbConfTemplate = `# -----------------------------------------------------------------------------
+# {{ .App.Name }} - Database Configuration
+#
+# Connection is opened on application start and closed on shutdown,
+# refer to 'app/db/db.go'.
+# -----------------------------------------------------------------------------
+
+db {
+  # Driver name registered with 'database/sql'.
+  driver = "{{ .App.DBDriver }}"
+
+  # Data source name, environment variable 'DB_DSN' overrides this value.
+  dsn = "{{ .App.DBDSN }}"
+
+  # Connection pool settings.{{ if eq .App.Database "sqlite" }}
+  # SQLite allows only one writer at a time.
+  max_open_conns = 1
+  max_idle_conns = 1{{ else }}
+  max_open_conns = 10
+  max_idle_conns = 5{{ end }}
+  conn_max_lifetime = "1h"
+
+  migrations {
+    # Applies pending migrations on application start.
+    auto_run = true
+
+    # Migrations directory relative to application base directory, files
+    # are applied in the lexical order of its names.
+    dir = "db/migrations"
+  }
+}
+`
+
+const dbTemplate = `package db
+
+import (
+	"database/sql"
+	"os"
+	"time"
+
+	"aahframe.work"
+
+	_ "{{ .App.DBDriverImport }}"
+)
+
+// DB is the application database handle, it is available after
+// application start.
+var DB *sql.DB
+
+// Connect method opens the database connection with 'db.*' configuration
+// and applies the pending migrations. It is registered with 'app.OnStart'
+// in 'app/init.go'.
+func Connect(_ *aah.Event) {
+	app := aah.App()
+	cfg := app.Config()
+
+	dsn := os.Getenv("DB_DSN")
+	if len(dsn) == 0 {
+		dsn = cfg.StringDefault("db.dsn", "")
+	}
+	driver := cfg.StringDefault("db.driver", "{{ .App.DBDriver }}")
+
+	var err error
+	if DB, err = sql.Open(driver, dsn); err != nil {
+		app.Log().Fatalf("db: unable to open connection: %v", err)
+	}
+	DB.SetMaxOpenConns(cfg.IntDefault("db.max_open_conns", 10))
+	DB.SetMaxIdleConns(cfg.IntDefault("db.max_idle_conns", 5))
+	if lifetime, err := time.ParseDuration(cfg.StringDefault("db.conn_max_lifetime", "1h")); err == nil {
+		DB.SetConnMaxLifetime(lifetime)
+	}
+
+	if err = DB.Ping(); err != nil {
+		app.Log().Fatalf("db: unable to connect: %v", err)
+	}
+	app.Log().Infof("db: connected [%s]", driver)
+
+	if cfg.BoolDefault("db.migrations.auto_run", true) {
+		if err = Migrate(DB, cfg.StringDefault("db.migrations.dir", "db/migrations")); err != nil {
+			app.Log().Fatalf("db: migration failed: %v", err)
+		}
+	}
+}
+
+// Close method closes the database connection. It is registered with
+// 'app.OnPostShutdown' in 'app/init.go'.
+func Close(_ *aah.Event) {
+	if DB == nil {
+		return
+	}
+	if err := DB.Close(); err != nil {
+		aah.App().Log().Errorf("db: unable to close connection: %v", err)
+	}
+}
+`
+
+const dbMigrateTemplate = `package db
+
+import (
+	"database/sql"
+	"path"
+	"sort"
+	"strings"
+
+	"aahframe.work"
+)
+
+// Migrate method applies the pending '*.sql' migrations from the given
+// directory in the lexical order of its names. Applied migrations are
+// tracked in the table 'schema_migrations'.
+//
+// Migrations are read from application virtual file system, so it works
+// for both 'aah run' and packaged binary.
+func Migrate(db *sql.DB, dir string) error {
+	app := aah.App()
+	if _, err := db.Exec("CREATE TABLE IF NOT EXISTS schema_migrations (version VARCHAR(255) PRIMARY KEY)"); err != nil {
+		return err
+	}
+
+	migrationsDir := path.Join(app.VirtualBaseDir(), dir)
+	infos, err := app.VFS().ReadDir(migrationsDir)
+	if err != nil {
+		return err
+	}
+
+	var versions []string
+	for _, info := range infos {
+		if !info.IsDir() && strings.HasSuffix(info.Name(), ".sql") {
+			versions = append(versions, info.Name())
+		}
+	}
+	sort.Strings(versions)
+
+	for _, version := range versions {
+		var applied int
+		if err = db.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE version = {{ .App.DBBindVar }}", version).Scan(&applied); err != nil {
+			return err
+		}
+		if applied > 0 {
+			continue
+		}
+
+		b, err := app.VFS().ReadFile(path.Join(migrationsDir, version))
+		if err != nil {
+			return err
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err = tx.Exec(string(b)); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if _, err = tx.Exec("INSERT INTO schema_migrations (version) VALUES ({{ .App.DBBindVar }})", version); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+		app.Log().Infof("db: migration applied '%s'", version)
+	}
+	return nil
+}
+`
+
+const dbInitMigration = `-- Initial schema, add your tables here. Migrations are applied in the
+-- lexical order of its file names, e.g. 0002_create_books.sql
+
+CREATE TABLE IF NOT EXISTS greetings (
+  id INTEGER PRIMARY KEY,
+  message VARCHAR(255) NOT NULL
+);
+`
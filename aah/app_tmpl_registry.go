@@ -0,0 +1,506 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"aahframe.work/config"
+	"aahframe.work/essentials"
+)
+
+const (
+	defaultAppTmplName     = "generic"
+	appTmplManifestName    = "aah.template"
+	appTmplRegistryName    = "app-templates.json"
+	appTmplRegistryDirName = "app-templates.d"
+)
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// App template registry and its methods
+//______________________________________________________________________________
+
+// appTmplRegistry struct holds the third-party app templates registered by
+// name, it's persisted at '<aahpath>/app-templates.json'.
+type appTmplRegistry struct {
+	Templates []*appTmplEntry `json:"templates,omitempty"`
+}
+
+// appTmplEntry struct holds the registered app template location. Location
+// could be local directory, tarball or git repository URL.
+type appTmplEntry struct {
+	Name     string `json:"name"`
+	Location string `json:"location"`
+	Ref      string `json:"ref,omitempty"`
+	Git      bool   `json:"git,omitempty"`
+}
+
+func (r *appTmplRegistry) Lookup(name string) *appTmplEntry {
+	for _, t := range r.Templates {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+func (r *appTmplRegistry) Register(name, location, ref string) error {
+	if ess.IsStrEmpty(name) || ess.IsStrEmpty(location) {
+		return fmt.Errorf("app template name and location is required to register")
+	}
+	if name == defaultAppTmplName {
+		return fmt.Errorf("app template name '%s' is reserved", defaultAppTmplName)
+	}
+	git := isGitURL(location)
+	switch {
+	case git:
+	case isRemoteURL(location):
+		// e.g. 'https://github.com/acme/app-templates' without '.git' suffix
+		if _, err := execCmd(gitcmd, []string{"ls-remote", "--heads", location}, false); err != nil {
+			return fmt.Errorf("Remote location '%s' is not a git repository, remote tarball is not supported, "+
+				"download it and register the local path", location)
+		}
+		git = true
+	default:
+		location = absPath(location)
+	}
+	if t := r.Lookup(name); t != nil {
+		t.Location, t.Ref, t.Git = location, ref, git
+	} else {
+		r.Templates = append(r.Templates, &appTmplEntry{Name: name, Location: location, Ref: ref, Git: git})
+	}
+	sort.Slice(r.Templates, func(i, j int) bool { return r.Templates[i].Name < r.Templates[j].Name })
+	return r.Persist()
+}
+
+func (r *appTmplRegistry) Persist() error {
+	registryPath := filepath.Join(aahPath(), appTmplRegistryName)
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(registryPath, b, permRWRWRW)
+}
+
+func loadAppTmplRegistry() *appTmplRegistry {
+	r := new(appTmplRegistry)
+	registryPath := filepath.Join(aahPath(), appTmplRegistryName)
+	if !ess.IsFileExists(registryPath) {
+		return r
+	}
+	b, err := ioutil.ReadFile(registryPath)
+	if err != nil {
+		logError(err)
+		return r
+	}
+	if err = json.Unmarshal(b, r); err != nil {
+		logErrorf("Unable to read app templates registry %s: %s", registryPath, err)
+	}
+	return r
+}
+
+// resolveAppTmpl method returns the app template base directory and its
+// manifest (if present) for the given template name.
+func resolveAppTmpl(name string) (string, *appTmplManifest, error) {
+	var baseDir string
+	if ess.IsStrEmpty(name) || name == defaultAppTmplName {
+		if baseDir = inferAppTmplBaseDir(); ess.IsStrEmpty(baseDir) {
+			return "", nil, fmt.Errorf("Unable to find aah app template, refer to 'aah help new' for app templates location")
+		}
+	} else {
+		t := loadAppTmplRegistry().Lookup(name)
+		if t == nil {
+			return "", nil, fmt.Errorf("App template '%s' is not registered, register it via "+
+				"'aah new --register-template %s --template-dir <location>'", name, name)
+		}
+
+		if t.Git || isGitURL(t.Location) {
+			gitDir := filepath.Join(aahPath(), appTmplRegistryDirName, name)
+			if err := syncAppTmplRepo(t.Location, gitDir, t.Ref, !ess.IsStrEmpty(t.Ref)); err != nil {
+				return "", nil, fmt.Errorf("App template '%s': %s", name, err)
+			}
+			baseDir = findAppTmplBaseDir(gitDir)
+		} else {
+			baseDir = localAppTmplBaseDir(t.Location)
+		}
+		if ess.IsStrEmpty(baseDir) {
+			return "", nil, fmt.Errorf("Unable to find app template '%s' at '%s'", name, t.Location)
+		}
+	}
+
+	manifestFile := filepath.Join(baseDir, appTmplManifestName)
+	if !ess.IsFileExists(manifestFile) {
+		return baseDir, nil, nil
+	}
+	m, err := loadAppTmplManifest(manifestFile)
+	return baseDir, m, err
+}
+
+// isGitURL method returns true if location is git repository URL by its form,
+// i.e. '.git' suffix, 'git@', 'ssh://' or 'git://'.
+func isGitURL(location string) bool {
+	return strings.HasSuffix(location, ".git") || strings.HasPrefix(location, "git@") ||
+		strings.HasPrefix(location, "ssh://") || strings.HasPrefix(location, "git://")
+}
+
+func isRemoteURL(location string) bool {
+	return strings.HasPrefix(location, "https://") || strings.HasPrefix(location, "http://")
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// App template manifest and its methods
+//______________________________________________________________________________
+
+// appTmplManifest struct holds the app template manifest 'aah.template'
+// details. Manifest file is aah config format, for e.g.:
+//
+//	name = "acme-api"
+//	desc = "ACME API starter with logging, metrics and auth wiring"
+//
+//	prompts {
+//	  # answers for the built-in prompts, these prompts are not asked
+//	  answers {
+//	    type = "api"
+//	  }
+//	  # template specific prompts, answer is accessible via {{ .App.Vars.team }}
+//	  custom {
+//	    team {
+//	      message = "Enter your team name"
+//	      default = "platform"
+//	      choices = ["platform", "payments"]
+//	    }
+//	  }
+//	}
+//
+//	files {
+//	  excludes = ["README.md"]
+//	  # rules are keyed on appTmplData field names or custom prompt names
+//	  rules {
+//	    security {
+//	      pattern = "app/security/**"
+//	      when {
+//	        AuthScheme = ["generic", "basic"]
+//	      }
+//	    }
+//	  }
+//	}
+//
+//	hooks {
+//	  # command line is split into arguments like shell does, use single or
+//	  # double quotes for argument with spaces
+//	  post_create = ["go mod tidy", "git init", "git commit -m 'Initial commit'"]
+//	}
+type appTmplManifest struct {
+	Name       string
+	Desc       string
+	Answers    *appAnswers
+	Prompts    []*appTmplPrompt
+	Excludes   []string
+	Rules      []*appTmplFileRule
+	PostCreate []string
+}
+
+type appTmplPrompt struct {
+	Key     string
+	Message string
+	Default string
+	Choices []string
+}
+
+// appTmplFileRule struct is applied on files matching the pattern. File is
+// included only if all conditions of 'when' satisfied and none of 'unless'.
+type appTmplFileRule struct {
+	Pattern string
+	When    map[string][]string
+	Unless  map[string][]string
+}
+
+func loadAppTmplManifest(manifestFile string) (*appTmplManifest, error) {
+	cfg, err := config.LoadFile(manifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("App template manifest '%s' is invalid: %s", manifestFile, err)
+	}
+
+	m := &appTmplManifest{
+		Name:    cfg.StringDefault("name", filepath.Base(filepath.Dir(manifestFile))),
+		Desc:    cfg.StringDefault("desc", ""),
+		Answers: answersFromConfig(cfg, "prompts.answers."),
+	}
+	m.Excludes, _ = cfg.StringList("files.excludes")
+	m.PostCreate, _ = cfg.StringList("hooks.post_create")
+
+	promptKeys := cfg.KeysByPath("prompts.custom")
+	sort.Strings(promptKeys)
+	for _, key := range promptKeys {
+		keyPrefix := "prompts.custom." + key
+		p := &appTmplPrompt{
+			Key:     key,
+			Message: cfg.StringDefault(keyPrefix+".message", "Enter "+key),
+			Default: cfg.StringDefault(keyPrefix+".default", ""),
+		}
+		p.Choices, _ = cfg.StringList(keyPrefix + ".choices")
+		m.Prompts = append(m.Prompts, p)
+	}
+
+	ruleKeys := cfg.KeysByPath("files.rules")
+	sort.Strings(ruleKeys)
+	for _, key := range ruleKeys {
+		keyPrefix := "files.rules." + key
+		r := &appTmplFileRule{
+			Pattern: cfg.StringDefault(keyPrefix+".pattern", ""),
+			When:    make(map[string][]string),
+			Unless:  make(map[string][]string),
+		}
+		if ess.IsStrEmpty(r.Pattern) {
+			return nil, fmt.Errorf("App template manifest '%s': rule '%s' is missing pattern", manifestFile, key)
+		}
+		for _, f := range cfg.KeysByPath(keyPrefix + ".when") {
+			r.When[f], _ = cfg.StringList(keyPrefix + ".when." + f)
+		}
+		for _, f := range cfg.KeysByPath(keyPrefix + ".unless") {
+			r.Unless[f], _ = cfg.StringList(keyPrefix + ".unless." + f)
+		}
+		m.Rules = append(m.Rules, r)
+	}
+
+	return m, nil
+}
+
+// ApplyAnswers method fills the manifest answers into user answers, answers
+// given by user takes precedence.
+func (m *appTmplManifest) ApplyAnswers(ans *appAnswers) {
+	ma := m.Answers
+	for _, v := range []struct{ dst, src *string }{
+		{&ans.ImportPath, &ma.ImportPath},
+		{&ans.Dir, &ma.Dir},
+		{&ans.Type, &ma.Type},
+		{&ans.ViewEngine, &ma.ViewEngine},
+		{&ans.AuthScheme, &ma.AuthScheme},
+		{&ans.BasicAuthMode, &ma.BasicAuthMode},
+		{&ans.PasswordHash, &ma.PasswordHash},
+		{&ans.SessionStore, &ma.SessionStore},
+		{&ans.Database, &ma.Database},
+		{&ans.OAuth2Provider, &ma.OAuth2Provider},
+		{&ans.OAuth2ClientIDEnv, &ma.OAuth2ClientIDEnv},
+		{&ans.OAuth2ClientSecretEnv, &ma.OAuth2ClientSecretEnv},
+		{&ans.OAuth2CallbackPath, &ma.OAuth2CallbackPath},
+		{&ans.OAuth2AuthURL, &ma.OAuth2AuthURL},
+		{&ans.OAuth2TokenURL, &ma.OAuth2TokenURL},
+	} {
+		if ess.IsStrEmpty(*v.dst) {
+			*v.dst = *v.src
+		}
+	}
+	if ans.CORS == nil {
+		ans.CORS = ma.CORS
+	}
+	if ans.SubTypes == nil {
+		ans.SubTypes = ma.SubTypes
+	}
+}
+
+// CollectVars method collects the answers for template specific prompts.
+func (m *appTmplManifest) CollectVars(ans *appAnswers) map[string]string {
+	vars := make(map[string]string)
+	for _, p := range m.Prompts {
+		prompt := "\n" + p.Message
+		if len(p.Choices) > 0 {
+			prompt += " (" + strings.Join(p.Choices, ", ") + ")"
+		}
+		if !ess.IsStrEmpty(p.Default) {
+			prompt += ", default is '" + p.Default + "'"
+		}
+		p := p
+		vars[p.Key] = collectInput(reader, ans, ans.Vars[p.Key], prompt+": ", func(v string) (string, error) {
+			if ess.IsStrEmpty(v) {
+				v = p.Default
+			}
+			if ess.IsStrEmpty(v) {
+				return "", fmt.Errorf("Value is required for '%s'", p.Key)
+			}
+			if len(p.Choices) > 0 && !ess.IsSliceContainsString(p.Choices, v) {
+				return "", fmt.Errorf("Unsupported value '%s' for '%s', choose one of '%s'", v, p.Key,
+					strings.Join(p.Choices, ", "))
+			}
+			return v, nil
+		})
+	}
+	return vars
+}
+
+// TmplFiles method returns the template files selected by manifest rules.
+func (m *appTmplManifest) TmplFiles(app *appTmplData, appTmplBaseDir, appBaseDir string) []file {
+	flist, _ := ess.FilesPath(appTmplBaseDir, true)
+	files := []file{}
+	for _, f := range flist {
+		rel := filepath.ToSlash(strings.TrimPrefix(f[len(appTmplBaseDir):], string(filepath.Separator)))
+		if rel == appTmplManifestName || strings.HasPrefix(rel, ".git/") || m.isExcluded(rel) ||
+			!m.isIncluded(app, rel) {
+			continue
+		}
+		files = append(files, file{src: f, dst: filepath.Join(appBaseDir, filepath.FromSlash(rel))})
+	}
+	return files
+}
+
+// PostCreateHooks method runs the manifest post create commands on the
+// created application base directory.
+func (m *appTmplManifest) PostCreateHooks(appBaseDir string, data map[string]interface{}) error {
+	if len(m.PostCreate) == 0 {
+		return nil
+	}
+	pwd, _ := os.Getwd()
+	if err := os.Chdir(appBaseDir); err != nil {
+		return err
+	}
+	defer func() { _ = os.Chdir(pwd) }()
+
+	for _, hook := range m.PostCreate {
+		var buf bytes.Buffer
+		if err := renderTmpl(&buf, hook, data); err != nil {
+			return fmt.Errorf("post create hook '%s': %s", hook, err)
+		}
+		args, err := splitShellWords(buf.String())
+		if err != nil {
+			return fmt.Errorf("post create hook '%s': %s", hook, err)
+		}
+		if len(args) == 0 {
+			continue
+		}
+		cliLog.Infof("Running post create hook: %s", buf.String())
+		if _, err := execCmd(args[0], args[1:], true); err != nil {
+			return fmt.Errorf("post create hook '%s': %s", hook, err)
+		}
+	}
+	return nil
+}
+
+func (m *appTmplManifest) isExcluded(rel string) bool {
+	for _, pattern := range m.Excludes {
+		if matchTmplPattern(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *appTmplManifest) isIncluded(app *appTmplData, rel string) bool {
+	for _, r := range m.Rules {
+		if !matchTmplPattern(r.Pattern, rel) {
+			continue
+		}
+		for field, values := range r.When {
+			if !app.matchField(field, values) {
+				return false
+			}
+		}
+		for field, values := range r.Unless {
+			if app.matchField(field, values) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// matchTmplPattern method matches the slash separated relative path with
+// pattern. Pattern suffix '/**' matches everything under the directory,
+// otherwise it's matched with path and file name using 'path.Match'.
+func matchTmplPattern(pattern, rel string) bool {
+	if strings.HasSuffix(pattern, "/**") {
+		return strings.HasPrefix(rel, strings.TrimSuffix(pattern, "**"))
+	}
+	if matched, _ := path.Match(pattern, rel); matched {
+		return true
+	}
+	matched, _ := path.Match(pattern, path.Base(rel))
+	return matched
+}
+
+// matchField method reports whether the value of appTmplData field or custom
+// prompt answer is one of the given values.
+func (a *appTmplData) matchField(name string, values []string) bool {
+	var fieldValues []string
+	if v, found := a.Vars[name]; found {
+		fieldValues = []string{v}
+	} else {
+		// only exported fields are accessible for the rules
+		if sf, found := reflect.TypeOf(a).Elem().FieldByName(name); !found || sf.PkgPath != "" {
+			return false
+		}
+		fv := reflect.ValueOf(a).Elem().FieldByName(name)
+		switch {
+		case fv.Kind() == reflect.Slice:
+			for i := 0; i < fv.Len(); i++ {
+				fieldValues = append(fieldValues, fmt.Sprint(fv.Index(i).Interface()))
+			}
+		default:
+			fieldValues = []string{fmt.Sprint(fv.Interface())}
+		}
+	}
+
+	for _, fv := range fieldValues {
+		if ess.IsSliceContainsString(values, fv) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitShellWords method splits the command line into arguments like POSIX
+// shell does, it supports single quotes, double quotes and backslash escape.
+// Variable expansion and other shell features are not supported.
+func splitShellWords(s string) ([]string, error) {
+	var args []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			if quote == '"' && r != '"' && r != '\\' && r != '$' && r != '`' {
+				word.WriteRune('\\')
+			}
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if escaped || quote != 0 {
+		return nil, fmt.Errorf("unterminated quote or escape in '%s'", s)
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+	return args, nil
+}
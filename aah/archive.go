@@ -0,0 +1,571 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"aahframe.work"
+	"aahframe.work/config"
+	"aahframe.work/console"
+	"aahframe.work/essentials"
+)
+
+const (
+	archiveFormatZip   = "zip"
+	archiveFormatTarGz = "tar.gz"
+	archiveFormatDir   = "dir"
+	archiveFormatOCI   = "oci"
+
+	ociMediaTypeManifest = "application/vnd.oci.image.manifest.v1+json"
+	ociMediaTypeConfig   = "application/vnd.oci.image.config.v1+json"
+	ociMediaTypeLayer    = "application/vnd.oci.image.layer.v1.tar+gzip"
+	ociImageRootDir      = "app"
+)
+
+// archiveFormatExts holds the artifact file suffix for each build format.
+var archiveFormatExts = map[string]string{
+	archiveFormatZip:   ".zip",
+	archiveFormatTarGz: ".tar.gz",
+	archiveFormatDir:   "",
+	archiveFormatOCI:   "-oci.tar",
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Build artifact methods
+//___________________________________
+
+// buildArchiveFormat method returns the artifact format from '--format' flag
+// otherwise 'build.format' from 'aah.project', default is 'zip'.
+func buildArchiveFormat(c *console.Context, projectCfg *config.Config) string {
+	format := strings.ToLower(strings.TrimSpace(c.String("format")))
+	if ess.IsStrEmpty(format) {
+		format = strings.ToLower(projectCfg.StringDefault("build.format", archiveFormatZip))
+	}
+	if _, found := archiveFormatExts[format]; !found {
+		logFatalf("Unsupported build format '%s', supported formats are 'zip', 'tar.gz', 'dir', 'oci'", format)
+	}
+	return format
+}
+
+// createArchive method creates the build artifact of given format from the
+// source, source is either the application build directory or single binary.
+func createArchive(format, src, destArchiveFile string, target buildTarget, projectCfg *config.Config) error {
+	ess.DeleteFiles(destArchiveFile)
+	if err := ess.MkDirAll(filepath.Dir(destArchiveFile), permRWXRXRX); err != nil {
+		return err
+	}
+
+	switch format {
+	case archiveFormatTarGz:
+		return createTarGzArchive(src, destArchiveFile)
+	case archiveFormatDir:
+		return createDirArchive(src, destArchiveFile)
+	case archiveFormatOCI:
+		return createOCIArchive(src, destArchiveFile, target, projectCfg)
+	}
+	return createZipArchive(src, destArchiveFile)
+}
+
+func createZipArchive(buildBaseDir, destArchiveFile string) error {
+	ess.DeleteFiles(destArchiveFile)
+
+	archiveBaseDir := filepath.Dir(destArchiveFile)
+	if err := ess.MkDirAll(archiveBaseDir, permRWXRXRX); err != nil {
+		return err
+	}
+	if reproducible.Enabled {
+		return createReproducibleZipArchive(buildBaseDir, destArchiveFile)
+	}
+	return ess.Zip(destArchiveFile, buildBaseDir)
+}
+
+// createReproducibleZipArchive method creates zip with entries in lexical
+// order and entry timestamps set to reproducible build epoch.
+func createReproducibleZipArchive(src, destArchiveFile string) error {
+	f, err := os.Create(destArchiveFile)
+	if err != nil {
+		return err
+	}
+	defer ess.CloseQuietly(f)
+
+	zw := zip.NewWriter(f)
+	baseDir := filepath.Dir(src)
+	if err = filepath.Walk(src, func(fpath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(baseDir, fpath)
+		if err != nil {
+			return err
+		}
+
+		hdr := &zip.FileHeader{Name: filepath.ToSlash(rel), Method: zip.Deflate, Modified: reproducible.Epoch}
+		hdr.SetMode(info.Mode())
+		if info.IsDir() {
+			hdr.Name += "/"
+			hdr.Method = zip.Store
+		}
+		w, err := zw.CreateHeader(hdr)
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+
+		rf, err := os.Open(fpath)
+		if err != nil {
+			return err
+		}
+		defer ess.CloseQuietly(rf)
+		_, err = io.Copy(w, rf)
+		return err
+	}); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func createTarGzArchive(src, destArchiveFile string) error {
+	f, err := os.Create(destArchiveFile)
+	if err != nil {
+		return err
+	}
+	defer ess.CloseQuietly(f)
+
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	if err = writeTarEntries(tw, src, filepath.Base(src)); err != nil {
+		return err
+	}
+	if err = tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+func createDirArchive(src, destDir string) error {
+	if fi, err := os.Stat(src); err == nil && !fi.IsDir() {
+		// single binary
+		binDir := filepath.Join(destDir, "bin")
+		if err = ess.MkDirAll(binDir, permRWXRXRX); err != nil {
+			return err
+		}
+		return copyFileWithMode(filepath.Join(binDir, filepath.Base(src)), src, fi.Mode())
+	}
+
+	return filepath.Walk(src, func(fpath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, fpath)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(destDir, rel)
+		if info.IsDir() {
+			return ess.MkDirAll(dest, info.Mode().Perm())
+		}
+		return copyFileWithMode(dest, fpath, info.Mode())
+	})
+}
+
+func copyFileWithMode(dest, src string, mode os.FileMode) error {
+	sf, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer ess.CloseQuietly(sf)
+
+	df, err := os.OpenFile(dest, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(df, sf); err != nil {
+		ess.CloseQuietly(df)
+		return err
+	}
+	return df.Close()
+}
+
+// writeTarEntries method writes the source file or directory tree into tar
+// under given root name, Unix file modes are preserved.
+func writeTarEntries(tw *tar.Writer, src, rootName string) error {
+	return filepath.Walk(src, func(fpath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, fpath)
+		if err != nil {
+			return err
+		}
+
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = path.Join(rootName, filepath.ToSlash(rel))
+		hdr.Uid, hdr.Gid, hdr.Uname, hdr.Gname = 0, 0, "", ""
+		if reproducible.Enabled {
+			hdr.ModTime, hdr.AccessTime, hdr.ChangeTime = reproducible.Epoch, time.Time{}, time.Time{}
+		}
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if err = tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(fpath)
+		if err != nil {
+			return err
+		}
+		defer ess.CloseQuietly(f)
+		_, err = io.Copy(tw, f)
+		return err
+	})
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// OCI image layout methods
+//___________________________________
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type ociLayer struct {
+	ociDescriptor
+	DiffID string
+	File   string
+}
+
+// createOCIArchive method creates OCI image layout tarball for the target,
+// it's built without Docker daemon. Image is based on 'scratch' or local base
+// layer tarball (tar or tar.gz) configured via 'build.oci.base_layer' e.g.
+// distroless rootfs. Container user, environment profile and image tag are
+// configured via 'build.oci.user', 'build.oci.env_profile' (default is 'prod')
+// and 'build.oci.tag' (default is application version) in 'aah.project'.
+func createOCIArchive(src, destArchiveFile string, target buildTarget, projectCfg *config.Config) error {
+	tmpDir, err := ioutil.TempDir("", "aah-oci")
+	if err != nil {
+		return fmt.Errorf("unable to get temp directory: %s", err)
+	}
+	defer ess.DeleteFiles(tmpDir)
+
+	app := aah.App()
+	var layers []*ociLayer
+
+	// base layer
+	if baseLayer := projectCfg.StringDefault("build.oci.base_layer", ""); !ess.IsStrEmpty(baseLayer) {
+		layer, err := createOCIBaseLayer(absPath(baseLayer), tmpDir)
+		if err != nil {
+			return fmt.Errorf("Unable to process OCI base layer '%s': %s", baseLayer, err)
+		}
+		layers = append(layers, layer)
+	}
+
+	// application layer
+	appName := strings.TrimSuffix(filepath.Base(src), ".exe")
+	appDir := path.Join(ociImageRootDir, appName)
+	var binaryName string
+	layer, err := createOCILayer(filepath.Join(tmpDir, "app-layer.tar.gz"), func(tw *tar.Writer) error {
+		fi, err := os.Stat(src)
+		if err != nil {
+			return err
+		}
+		if err = tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: ociImageRootDir + "/",
+			Mode: int64(permRWXRXRX), ModTime: modTime(fi.ModTime())}); err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			binaryName = filepath.Base(appBinaryFile(projectCfg, "", target.GOOS))
+			return writeTarEntries(tw, src, appDir)
+		}
+		binaryName = filepath.Base(src)
+		for _, dir := range []string{appDir, path.Join(appDir, "bin")} {
+			if err = tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: dir + "/",
+				Mode: int64(permRWXRXRX), ModTime: modTime(fi.ModTime())}); err != nil {
+				return err
+			}
+		}
+		return writeTarEntries(tw, src, path.Join(appDir, "bin", binaryName))
+	})
+	if err != nil {
+		return fmt.Errorf("Unable to create OCI application layer: %s", err)
+	}
+	layers = append(layers, layer)
+
+	// image config
+	envProfile := projectCfg.StringDefault("build.oci.env_profile", "prod")
+	imgConfig := map[string]interface{}{
+		"created":      modTime(time.Now()).UTC().Format(time.RFC3339),
+		"architecture": target.GOARCH,
+		"os":           target.GOOS,
+		"config": map[string]interface{}{
+			"Entrypoint":   []string{"/" + path.Join(appDir, "bin", binaryName)},
+			"Cmd":          []string{"run", "--envprofile", envProfile},
+			"WorkingDir":   "/" + appDir,
+			"ExposedPorts": map[string]struct{}{appPortForScript(app.Config()) + "/tcp": {}},
+			"User":         projectCfg.StringDefault("build.oci.user", ""),
+		},
+		"rootfs": map[string]interface{}{
+			"type":     "layers",
+			"diff_ids": ociDiffIDs(layers),
+		},
+	}
+	configJSON, err := json.Marshal(imgConfig)
+	if err != nil {
+		return err
+	}
+	configDesc := ociDescriptor{MediaType: ociMediaTypeConfig, Digest: sha256Digest(configJSON), Size: int64(len(configJSON))}
+
+	// image manifest
+	manifest := map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     ociMediaTypeManifest,
+		"config":        configDesc,
+		"layers":        ociLayerDescriptors(layers),
+	}
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+
+	tag := projectCfg.StringDefault("build.oci.tag", getAppVersion(app.BaseDir(), projectCfg))
+	index := map[string]interface{}{
+		"schemaVersion": 2,
+		"manifests": []ociDescriptor{{
+			MediaType:   ociMediaTypeManifest,
+			Digest:      sha256Digest(manifestJSON),
+			Size:        int64(len(manifestJSON)),
+			Annotations: map[string]string{"org.opencontainers.image.ref.name": tag},
+		}},
+	}
+	indexJSON, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+
+	// OCI image layout tarball
+	f, err := os.Create(destArchiveFile)
+	if err != nil {
+		return err
+	}
+	defer ess.CloseQuietly(f)
+
+	tw := tar.NewWriter(f)
+	for _, dir := range []string{"blobs/", "blobs/sha256/"} {
+		if err = tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: dir, Mode: int64(permRWXRXRX), ModTime: modTime(time.Now())}); err != nil {
+			return err
+		}
+	}
+	entries := []struct {
+		name string
+		data []byte
+	}{
+		{"oci-layout", []byte(`{"imageLayoutVersion":"1.0.0"}`)},
+		{"index.json", indexJSON},
+		{ociBlobPath(configDesc.Digest), configJSON},
+		{ociBlobPath(sha256Digest(manifestJSON)), manifestJSON},
+	}
+	for _, e := range entries {
+		if err = writeTarBytes(tw, e.name, e.data); err != nil {
+			return err
+		}
+	}
+	for _, l := range layers {
+		if err = writeTarFile(tw, ociBlobPath(l.Digest), l.File); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// createOCILayer method creates gzipped tar layer, computes its digest and
+// diff ID (digest of uncompressed tar) in single pass.
+func createOCILayer(layerFile string, fn func(tw *tar.Writer) error) (*ociLayer, error) {
+	f, err := os.Create(layerFile)
+	if err != nil {
+		return nil, err
+	}
+	defer ess.CloseQuietly(f)
+
+	digestHash, diffIDHash := sha256.New(), sha256.New()
+	bw := bufio.NewWriter(io.MultiWriter(f, digestHash))
+	gw := gzip.NewWriter(bw)
+	tw := tar.NewWriter(io.MultiWriter(gw, diffIDHash))
+	if err = fn(tw); err != nil {
+		return nil, err
+	}
+	if err = tw.Close(); err != nil {
+		return nil, err
+	}
+	if err = gw.Close(); err != nil {
+		return nil, err
+	}
+	if err = bw.Flush(); err != nil {
+		return nil, err
+	}
+	return newOCILayer(layerFile, digestHash, diffIDHash)
+}
+
+// createOCIBaseLayer method prepares the local base layer tarball, it's
+// gzipped if it's not already.
+func createOCIBaseLayer(baseLayer, tmpDir string) (*ociLayer, error) {
+	f, err := os.Open(baseLayer)
+	if err != nil {
+		return nil, err
+	}
+	defer ess.CloseQuietly(f)
+
+	br := bufio.NewReader(f)
+	magic, _ := br.Peek(2)
+	digestHash, diffIDHash := sha256.New(), sha256.New()
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		layerFile := filepath.Join(tmpDir, "base-layer.tar.gz")
+		out, err := os.Create(layerFile)
+		if err != nil {
+			return nil, err
+		}
+		defer ess.CloseQuietly(out)
+		gr, err := gzip.NewReader(io.TeeReader(br, io.MultiWriter(out, digestHash)))
+		if err != nil {
+			return nil, err
+		}
+		if _, err = io.Copy(diffIDHash, gr); err != nil {
+			return nil, err
+		}
+		return newOCILayer(layerFile, digestHash, diffIDHash)
+	}
+
+	layerFile := filepath.Join(tmpDir, "base-layer.tar.gz")
+	out, err := os.Create(layerFile)
+	if err != nil {
+		return nil, err
+	}
+	defer ess.CloseQuietly(out)
+	gw := gzip.NewWriter(io.MultiWriter(out, digestHash))
+	if _, err = io.Copy(io.MultiWriter(gw, diffIDHash), br); err != nil {
+		return nil, err
+	}
+	if err = gw.Close(); err != nil {
+		return nil, err
+	}
+	return newOCILayer(layerFile, digestHash, diffIDHash)
+}
+
+func newOCILayer(layerFile string, digestHash, diffIDHash hash.Hash) (*ociLayer, error) {
+	fi, err := os.Stat(layerFile)
+	if err != nil {
+		return nil, err
+	}
+	return &ociLayer{
+		ociDescriptor: ociDescriptor{
+			MediaType: ociMediaTypeLayer,
+			Digest:    "sha256:" + hex.EncodeToString(digestHash.Sum(nil)),
+			Size:      fi.Size(),
+		},
+		DiffID: "sha256:" + hex.EncodeToString(diffIDHash.Sum(nil)),
+		File:   layerFile,
+	}, nil
+}
+
+func ociDiffIDs(layers []*ociLayer) []string {
+	var diffIDs []string
+	for _, l := range layers {
+		diffIDs = append(diffIDs, l.DiffID)
+	}
+	return diffIDs
+}
+
+func ociLayerDescriptors(layers []*ociLayer) []ociDescriptor {
+	var descs []ociDescriptor
+	for _, l := range layers {
+		descs = append(descs, l.ociDescriptor)
+	}
+	return descs
+}
+
+func ociBlobPath(digest string) string {
+	return path.Join("blobs", "sha256", strings.TrimPrefix(digest, "sha256:"))
+}
+
+// pathChecksum method returns SHA-256 checksum of the file. For directory,
+// it's computed over the sorted relative paths, modes and file contents.
+func pathChecksum(p string) (string, error) {
+	h := sha256.New()
+	err := filepath.Walk(p, func(fpath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fpath != p {
+			rel, _ := filepath.Rel(p, fpath)
+			_, _ = fmt.Fprintf(h, "%s %s\n", filepath.ToSlash(rel), info.Mode())
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(fpath)
+		if err != nil {
+			return err
+		}
+		defer ess.CloseQuietly(f)
+		_, err = io.Copy(h, f)
+		return err
+	})
+	return hex.EncodeToString(h.Sum(nil)), err
+}
+
+func sha256Digest(b []byte) string {
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func writeTarBytes(tw *tar.Writer, name string, data []byte) error {
+	if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: int64(permRWRWRW),
+		Size: int64(len(data)), ModTime: modTime(time.Now())}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+func writeTarFile(tw *tar.Writer, name, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer ess.CloseQuietly(f)
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err = tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: int64(permRWRWRW),
+		Size: fi.Size(), ModTime: modTime(time.Now())}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
@@ -0,0 +1,398 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// assetMinifiers holds the built-in minifiers by file extension. Minifiers
+// are conservative, it removes comments and whitespace only, so content
+// semantics stays as-is.
+var assetMinifiers = map[string]func(b []byte) ([]byte, error){
+	"css":  minifyCSS,
+	"js":   minifyJS,
+	"mjs":  minifyJS,
+	"svg":  minifySVG,
+	"json": minifyJSON,
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// CSS minifier
+//___________________________________
+
+// minifyCSS method removes comments (except '/*! ... */' license comments)
+// and whitespace around '{', '}', ';', ',', '>' and after ':'.
+func minifyCSS(b []byte) ([]byte, error) {
+	out := &bytes.Buffer{}
+	space := false
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case c == '"' || c == '\'':
+			end, err := scanQuoted(b, i)
+			if err != nil {
+				return nil, err
+			}
+			writeCSSSpace(out, space, c)
+			space = false
+			out.Write(b[i:end])
+			i = end - 1
+		case c == '/' && i+1 < len(b) && b[i+1] == '*':
+			end := bytes.Index(b[i+2:], []byte("*/"))
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated comment at offset %d", i)
+			}
+			end += i + 4
+			if i+2 < len(b) && b[i+2] == '!' {
+				writeCSSSpace(out, space, c)
+				space = false
+				out.Write(b[i:end])
+			}
+			i = end - 1
+		case isSpace(c):
+			space = true
+		default:
+			if c == '}' && lastByte(out) == ';' {
+				out.Truncate(out.Len() - 1)
+			}
+			writeCSSSpace(out, space, c)
+			space = false
+			out.WriteByte(c)
+		}
+	}
+	return bytes.TrimSpace(out.Bytes()), nil
+}
+
+func writeCSSSpace(out *bytes.Buffer, space bool, next byte) {
+	if !space || out.Len() == 0 {
+		return
+	}
+	if strings.IndexByte("{};,>:", lastByte(out)) >= 0 || strings.IndexByte("{};,>", next) >= 0 {
+		return
+	}
+	out.WriteByte(' ')
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// JS minifier
+//___________________________________
+
+// regexp literal may follow these keywords
+var jsRegexpKeywords = map[string]bool{"return": true, "typeof": true, "instanceof": true,
+	"in": true, "of": true, "new": true, "delete": true, "void": true, "throw": true,
+	"case": true, "do": true, "else": true, "yield": true, "await": true}
+
+// minifyJS method removes comments (except '/*! ... */' license comments),
+// leading and trailing whitespace of lines, blank lines and whitespace
+// around punctuators. Line breaks are retained to keep automatic semicolon
+// insertion behavior intact.
+func minifyJS(b []byte) ([]byte, error) {
+	out := &bytes.Buffer{}
+	space, newline := false, false
+	var braces []int // brace depth of each template literal substitution
+	depth := 0
+
+	flush := func(next byte) {
+		if out.Len() > 0 {
+			if newline {
+				out.WriteByte('\n')
+			} else if space && !isJSPunct(lastByte(out)) && !isJSPunct(next) {
+				out.WriteByte(' ')
+			}
+		}
+		space, newline = false, false
+	}
+
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case c == '\n' || c == '\r':
+			newline = true
+		case isSpace(c):
+			space = true
+		case c == '/' && i+1 < len(b) && b[i+1] == '/':
+			for i < len(b) && b[i] != '\n' {
+				i++
+			}
+			newline = true
+		case c == '/' && i+1 < len(b) && b[i+1] == '*':
+			end := bytes.Index(b[i+2:], []byte("*/"))
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated comment at offset %d", i)
+			}
+			end += i + 4
+			if b[i+2] == '!' {
+				flush(c)
+				out.Write(b[i:end])
+				newline = true
+			} else if bytes.ContainsAny(b[i:end], "\r\n") {
+				newline = true
+			} else {
+				space = true
+			}
+			i = end - 1
+		case c == '"' || c == '\'':
+			end, err := scanQuoted(b, i)
+			if err != nil {
+				return nil, err
+			}
+			flush(c)
+			out.Write(b[i:end])
+			i = end - 1
+		case c == '`' || (c == '}' && len(braces) > 0 && braces[len(braces)-1] == depth):
+			// template literal or continuation after substitution
+			if c == '}' {
+				braces = braces[:len(braces)-1]
+			}
+			end, subst, err := scanTemplate(b, i)
+			if err != nil {
+				return nil, err
+			}
+			flush(c)
+			out.Write(b[i:end])
+			if subst {
+				braces = append(braces, depth)
+			}
+			i = end - 1
+		case c == '/' && jsRegexpAllowed(out):
+			end, err := scanRegexp(b, i)
+			if err != nil {
+				return nil, err
+			}
+			flush(c)
+			out.Write(b[i:end])
+			i = end - 1
+		default:
+			switch c {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+			flush(c)
+			out.WriteByte(c)
+		}
+	}
+	return append(bytes.TrimSpace(out.Bytes()), '\n'), nil
+}
+
+// isJSPunct method reports whether whitespace next to the char can be
+// removed. '+', '-', '/' and '.' are not included since removal may change
+// the meaning, e.g. 'a + +b', '1 .toString()'.
+func isJSPunct(c byte) bool {
+	return strings.IndexByte("{}()[];,:=<>?&|!*%^~\n", c) >= 0
+}
+
+// jsRegexpAllowed method reports whether '/' starts a regexp literal based on
+// the preceding token. After ')', ']', '}', identifier, number and postfix
+// '++', '--' it's a division. '}' is treated as end of an expression, since
+// block followed by a regexp literal is rare compared to object literal or
+// function expression followed by a division.
+func jsRegexpAllowed(out *bytes.Buffer) bool {
+	s := bytes.TrimRight(out.Bytes(), " \n")
+	if len(s) == 0 {
+		return true
+	}
+	last := s[len(s)-1]
+	if (last == '+' || last == '-') && len(s) > 1 && s[len(s)-2] == last {
+		return false
+	}
+	if strings.IndexByte("(,=:[!&|?{;+-*%<>~^", last) >= 0 {
+		return true
+	}
+	i := len(s)
+	for i > 0 && isIdentChar(s[i-1]) {
+		i--
+	}
+	return jsRegexpKeywords[string(s[i:])]
+}
+
+// scanTemplate method returns the end offset of template literal part that
+// starts at offset i ('`' or '}'), subst is true if it ends at '${'.
+func scanTemplate(b []byte, i int) (int, bool, error) {
+	for j := i + 1; j < len(b); j++ {
+		switch b[j] {
+		case '\\':
+			j++
+		case '`':
+			return j + 1, false, nil
+		case '$':
+			if j+1 < len(b) && b[j+1] == '{' {
+				return j + 2, true, nil
+			}
+		}
+	}
+	return 0, false, fmt.Errorf("unterminated template literal at offset %d", i)
+}
+
+func scanRegexp(b []byte, i int) (int, error) {
+	class := false
+	for j := i + 1; j < len(b); j++ {
+		switch b[j] {
+		case '\\':
+			j++
+		case '[':
+			class = true
+		case ']':
+			class = false
+		case '\n':
+			return 0, fmt.Errorf("unterminated regexp literal at offset %d", i)
+		case '/':
+			if !class {
+				j++
+				for j < len(b) && isIdentChar(b[j]) {
+					j++
+				}
+				return j, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unterminated regexp literal at offset %d", i)
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// SVG and JSON minifier
+//___________________________________
+
+// svg elements whose text content is retained as-is
+var svgPreserveElements = []string{"text", "tspan", "textPath", "style", "script", "title", "desc"}
+
+// minifySVG method removes XML comments and whitespace-only text between
+// tags, text content of elements like 'text', 'style' is retained.
+func minifySVG(b []byte) ([]byte, error) {
+	out := &bytes.Buffer{}
+	preserve := 0
+	for i := 0; i < len(b); {
+		switch {
+		case bytes.HasPrefix(b[i:], []byte("<!--")):
+			end := bytes.Index(b[i:], []byte("-->"))
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated comment at offset %d", i)
+			}
+			i += end + 3
+		case bytes.HasPrefix(b[i:], []byte("<![CDATA[")):
+			end := bytes.Index(b[i:], []byte("]]>"))
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated CDATA at offset %d", i)
+			}
+			out.Write(b[i : i+end+3])
+			i += end + 3
+		case b[i] == '<':
+			end := svgTagEnd(b[i:])
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated tag at offset %d", i)
+			}
+			tag := b[i : i+end+1]
+			if name := svgTagName(tag); isSVGPreserveElement(name) {
+				switch {
+				case tag[1] == '/':
+					preserve--
+				case !bytes.HasSuffix(tag, []byte("/>")):
+					preserve++
+				}
+			}
+			out.Write(tag)
+			i += end + 1
+		default:
+			end := bytes.IndexByte(b[i:], '<')
+			if end == -1 {
+				end = len(b) - i
+			}
+			text := b[i : i+end]
+			if preserve > 0 {
+				out.Write(text)
+			} else if t := bytes.TrimSpace(text); len(t) > 0 {
+				out.Write(bytes.Join(bytes.Fields(text), []byte(" ")))
+			}
+			i += end
+		}
+	}
+	return bytes.TrimSpace(out.Bytes()), nil
+}
+
+// svgTagEnd method returns the offset of tag closing '>', quoted attribute
+// values are skipped.
+func svgTagEnd(b []byte) int {
+	var q byte
+	for i, c := range b {
+		switch {
+		case q != 0:
+			if c == q {
+				q = 0
+			}
+		case c == '"' || c == '\'':
+			q = c
+		case c == '>':
+			return i
+		}
+	}
+	return -1
+}
+
+func svgTagName(tag []byte) string {
+	name := bytes.TrimLeft(tag[1:], "/")
+	if i := bytes.IndexAny(name, " \t\r\n/>"); i >= 0 {
+		name = name[:i]
+	}
+	return string(name)
+}
+
+func isSVGPreserveElement(name string) bool {
+	for _, n := range svgPreserveElements {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func minifyJSON(b []byte) ([]byte, error) {
+	out := &bytes.Buffer{}
+	if err := json.Compact(out, b); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Unexported methods
+//___________________________________
+
+// scanQuoted method returns the end offset of quoted string that starts at
+// offset i.
+func scanQuoted(b []byte, i int) (int, error) {
+	q := b[i]
+	for j := i + 1; j < len(b); j++ {
+		switch b[j] {
+		case '\\':
+			j++
+		case q:
+			return j + 1, nil
+		case '\n':
+			return 0, fmt.Errorf("unterminated string at offset %d", i)
+		}
+	}
+	return 0, fmt.Errorf("unterminated string at offset %d", i)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+		(c >= '0' && c <= '9') || c >= 0x80
+}
+
+func lastByte(buf *bytes.Buffer) byte {
+	if buf.Len() == 0 {
+		return 0
+	}
+	return buf.Bytes()[buf.Len()-1]
+}
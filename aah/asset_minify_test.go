@@ -0,0 +1,91 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package main
+
+import "testing"
+
+func TestMinifyJS(t *testing.T) {
+	testcases := []struct {
+		label    string
+		src      string
+		expected string
+	}{
+		{label: "whitespace and comments", src: "// comment\nvar a = 1 ;  /* inline */ var b = a ;\n\n\n",
+			expected: "var a=1;var b=a;\n"},
+		{label: "license comment", src: "/*! license */\nvar a = 1",
+			expected: "/*! license */\nvar a=1\n"},
+		{label: "string retained", src: "var s = \"a  // b\" + 'c /* d */ \\' e'",
+			expected: "var s=\"a  // b\" + 'c /* d */ \\' e'\n"},
+		{label: "regexp after assign", src: "var re = /a  b\\/[/]c/gi ;",
+			expected: "var re=/a  b\\/[/]c/gi;\n"},
+		{label: "regexp after paren and keyword", src: "if ( /x  y/.test(s) ) return /a  b/",
+			expected: "if(/x  y/.test(s))return /a  b/\n"},
+		{label: "regexp after unary", src: "var a = + /x  y/.source",
+			expected: "var a=+ /x  y/.source\n"},
+		{label: "template literal", src: "var t = `a  ${ b + `c  ${ d }` }  e` ;",
+			expected: "var t=`a  ${b + `c  ${d}`}  e`;\n"},
+		{label: "template literal with object", src: "var t = `${ f({ a : 1 }) }  x`",
+			expected: "var t=`${f({a:1})}  x`\n"},
+		{label: "division after identifier and paren", src: "var c = a / 2 / (b) / 3",
+			expected: "var c=a / 2 /(b)/ 3\n"},
+		{label: "division after postfix", src: "var c = a++ / 2, d = b-- / 4",
+			expected: "var c=a++ / 2,d=b-- / 4\n"},
+		{label: "division after brace", src: "var c = {}.x / 2 + f(function(){ return 1 }) / 4",
+			expected: "var c={}.x / 2 + f(function(){return 1})/ 4\n"},
+		{label: "division after closing brace", src: "var n = {} / 2",
+			expected: "var n={}/ 2\n"},
+		{label: "automatic semicolon insertion", src: "var a = b\n(function () {})()\nreturn\nx\ni\n++\nj",
+			expected: "var a=b\n(function(){})()\nreturn\nx\ni\n++\nj\n"},
+		{label: "unary operators retained", src: "var a = b + +c - -d",
+			expected: "var a=b + +c - -d\n"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.label, func(t *testing.T) {
+			result, err := minifyJS([]byte(tc.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(result) != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestMinifyJSError(t *testing.T) {
+	for _, src := range []string{"var s = 'abc", "var t = `abc", "var re = /abc\n", "/* abc"} {
+		if _, err := minifyJS([]byte(src)); err == nil {
+			t.Errorf("expected error for %q", src)
+		}
+	}
+}
+
+func TestMinifyCSS(t *testing.T) {
+	testcases := []struct {
+		label    string
+		src      string
+		expected string
+	}{
+		{label: "whitespace and comments", src: "/* c */\na > b ,  c {\n  color: red ;\n  margin: 0 auto;\n}\n",
+			expected: "a>b,c{color:red;margin:0 auto}"},
+		{label: "space before colon retained", src: "a :hover { color: red }",
+			expected: "a :hover{color:red}"},
+		{label: "license comment and string", src: "/*! license */\na { content: \"a  ;  b\" }",
+			expected: "/*! license */ a{content:\"a  ;  b\"}"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.label, func(t *testing.T) {
+			result, err := minifyCSS([]byte(tc.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(result) != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, result)
+			}
+		})
+	}
+}
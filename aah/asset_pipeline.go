@@ -0,0 +1,455 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"aahframe.work"
+	"aahframe.work/config"
+	"aahframe.work/essentials"
+)
+
+const (
+	defaultAssetManifestName = "asset-manifest.json"
+	assetFileArg             = "{file}"
+)
+
+// assetBuildResult holds the asset pipeline result of current build, it's nil
+// if pipeline is not enabled via 'build.assets.enable' in 'aah.project'.
+var assetBuildResult *assetPipeline
+
+// assetProcessorFunc type is the asset pipeline step, it transforms the
+// asset content in-place.
+type assetProcessorFunc func(p *assetPipeline, a *asset) error
+
+// assetProcessors holds the built-in asset pipeline steps by name. External
+// command steps are configured via 'build.assets.commands' in 'aah.project'.
+var assetProcessors = map[string]assetProcessorFunc{
+	"minify": minifyAsset,
+}
+
+// asset struct holds the asset file content going through the pipeline.
+type asset struct {
+	Path string // slash separated path relative to assets directory
+	Ext  string // lower case extension without dot
+	Data []byte
+}
+
+// assetCommand struct holds the external command pipeline step, placeholder
+// '{file}' in the command arguments is replaced with asset file path.
+type assetCommand struct {
+	Command    []string
+	Extensions map[string]bool
+}
+
+// assetPipeline struct holds the asset pipeline settings and its result.
+type assetPipeline struct {
+	SrcDir   string
+	OutDir   string
+	Manifest map[string]string
+
+	steps        []string
+	commands     map[string]*assetCommand
+	minifyTypes  map[string]bool
+	fingerprint  bool
+	hashLength   int
+	keepOriginal bool
+	excludes     ess.Excludes
+	skips        ess.Excludes
+	manifestName string
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Asset pipeline methods
+//___________________________________
+
+// processAssetPipeline method runs the asset pipeline on configured assets
+// directory (default 'static') into staging directory. Single binary embed
+// and non-single binary packaging picks up the files from staging directory.
+//
+//	build {
+//	  assets {
+//	    enable = true
+//	    dir = "static"
+//	    processors = ["minify"]
+//	    minify = ["css", "js", "svg", "json"]
+//	    fingerprint = true
+//	    hash_length = 8
+//	    keep_original = true
+//	    skip = ["*.min.*"]
+//	    manifest = "asset-manifest.json"
+//	    url_prefix = "/static"
+//	    template_func = "asset"
+//	    commands {
+//	      autoprefixer {
+//	        command = ["npx", "postcss", "--use", "autoprefixer", "-r", "{file}"]
+//	        extensions = ["css"]
+//	      }
+//	    }
+//	  }
+//	}
+func processAssetPipeline(projectCfg *config.Config) {
+	if !projectCfg.BoolDefault("build.assets.enable", false) {
+		return
+	}
+
+	p, err := newAssetPipeline(projectCfg, aah.App().BaseDir())
+	if err != nil {
+		logFatal(err)
+	}
+	if !ess.IsFileExists(p.SrcDir) {
+		cliLog.Warnf("Assets directory does not exists, skip asset pipeline: %s", p.SrcDir)
+		return
+	}
+
+	cliLog.Infof("Asset pipeline starts for '%s'", p.SrcDir)
+	if err = p.Run(); err != nil {
+		p.Cleanup()
+		logFatal(err)
+	}
+	assetBuildResult = p
+	cliLog.Infof("Asset pipeline successful, %d assets fingerprinted", len(p.Manifest))
+}
+
+// cleanupAssetPipeline method removes the asset pipeline staging directory.
+func cleanupAssetPipeline() {
+	if assetBuildResult != nil {
+		assetBuildResult.Cleanup()
+		assetBuildResult = nil
+	}
+}
+
+func newAssetPipeline(projectCfg *config.Config, appBaseDir string) (*assetPipeline, error) {
+	srcDir := projectCfg.StringDefault("build.assets.dir", "static")
+	if !filepath.IsAbs(srcDir) {
+		srcDir = filepath.Join(appBaseDir, srcDir)
+	}
+
+	excludes, _ := projectCfg.StringList("build.excludes")
+	skips, _ := projectCfg.StringList("build.assets.skip")
+	p := &assetPipeline{
+		SrcDir:       filepath.Clean(srcDir),
+		Manifest:     make(map[string]string),
+		commands:     make(map[string]*assetCommand),
+		minifyTypes:  make(map[string]bool),
+		fingerprint:  projectCfg.BoolDefault("build.assets.fingerprint", true),
+		hashLength:   projectCfg.IntDefault("build.assets.hash_length", 8),
+		keepOriginal: projectCfg.BoolDefault("build.assets.keep_original", true),
+		excludes:     ess.Excludes(excludes),
+		skips:        ess.Excludes(skips),
+		manifestName: projectCfg.StringDefault("build.assets.manifest", defaultAssetManifestName),
+	}
+	if err := p.excludes.Validate(); err != nil {
+		return nil, err
+	}
+	if err := p.skips.Validate(); err != nil {
+		return nil, err
+	}
+	if p.hashLength < 4 || p.hashLength > sha256.Size*2 {
+		return nil, fmt.Errorf("build.assets.hash_length must be between 4 and %d", sha256.Size*2)
+	}
+
+	minifyTypes, found := projectCfg.StringList("build.assets.minify")
+	if !found {
+		minifyTypes = []string{"css", "js", "svg", "json"}
+	}
+	for _, t := range minifyTypes {
+		t = normalizeExt(t)
+		if _, found := assetMinifiers[t]; !found {
+			return nil, fmt.Errorf("Unsupported build.assets.minify type '%s'", t)
+		}
+		p.minifyTypes[t] = true
+	}
+
+	for _, name := range projectCfg.KeysByPath("build.assets.commands") {
+		keyPrefix := "build.assets.commands." + name
+		command, _ := projectCfg.StringList(keyPrefix + ".command")
+		if len(command) == 0 {
+			return nil, fmt.Errorf("'%s.command' is required", keyPrefix)
+		}
+		exts, _ := projectCfg.StringList(keyPrefix + ".extensions")
+		cmd := &assetCommand{Command: command, Extensions: make(map[string]bool)}
+		for _, ext := range exts {
+			cmd.Extensions[normalizeExt(ext)] = true
+		}
+		p.commands[name] = cmd
+	}
+
+	steps, found := projectCfg.StringList("build.assets.processors")
+	if !found {
+		steps = []string{"minify"}
+	}
+	for _, step := range steps {
+		_, builtin := assetProcessors[step]
+		if _, command := p.commands[step]; !builtin && !command {
+			return nil, fmt.Errorf("Unknown build.assets.processors step '%s'", step)
+		}
+	}
+	p.steps = steps
+
+	return p, nil
+}
+
+// Run method processes each file of assets directory through the pipeline
+// steps, fingerprints it and writes the asset manifest.
+func (p *assetPipeline) Run() error {
+	tmpDir, err := ioutil.TempDir("", "aah-assets")
+	if err != nil {
+		return fmt.Errorf("Unable to create asset staging directory: %s", err)
+	}
+	p.OutDir = filepath.Join(tmpDir, filepath.Base(p.SrcDir))
+
+	var (
+		dirs    []string
+		dirTime = make(map[string]time.Time)
+		latest  time.Time
+	)
+	if err = filepath.Walk(p.SrcDir, func(fpath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fpath != p.SrcDir && p.excludes.Match(info.Name()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, _ := filepath.Rel(p.SrcDir, fpath)
+		dest := filepath.Join(p.OutDir, rel)
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		if info.IsDir() {
+			dirs = append(dirs, dest)
+			dirTime[dest] = info.ModTime()
+			return ess.MkDirAll(dest, permRWXRXRX)
+		}
+		return p.processFile(fpath, filepath.ToSlash(rel), info)
+	}); err != nil {
+		return err
+	}
+
+	manifestFile := filepath.Join(p.OutDir, p.manifestName)
+	if err = writeJSONFile(manifestFile, p.Manifest); err != nil {
+		return err
+	}
+	if err = os.Chtimes(manifestFile, latest, latest); err != nil {
+		return err
+	}
+
+	// directory mod time is restored at last, since writing files into it
+	// updates the mod time
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err = os.Chtimes(dirs[i], dirTime[dirs[i]], dirTime[dirs[i]]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// String method returns the pipeline settings in stable order, it's part of
+// the VFS mount fingerprint.
+func (p *assetPipeline) String() string {
+	var types []string
+	for t := range p.minifyTypes {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return fmt.Sprintf("dir:%s steps:%s minify:%s fingerprint:%t hashlen:%d original:%t skip:%s manifest:%s",
+		p.SrcDir, strings.Join(p.steps, ","), strings.Join(types, ","), p.fingerprint,
+		p.hashLength, p.keepOriginal, strings.Join([]string(p.skips), ","), p.manifestName)
+}
+
+// Cleanup method removes the staging directory.
+func (p *assetPipeline) Cleanup() {
+	if !ess.IsStrEmpty(p.OutDir) {
+		ess.DeleteFiles(filepath.Dir(p.OutDir))
+	}
+}
+
+// ReplaceDir method replaces the assets directory of the given base
+// directory with pipeline output, used for non-single binary packaging.
+func (p *assetPipeline) ReplaceDir(appBaseDir, buildBaseDir string) error {
+	rel, err := filepath.Rel(appBaseDir, p.SrcDir)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		cliLog.Warnf("Assets directory is not within application base directory, skip: %s", p.SrcDir)
+		return nil
+	}
+	destDir := filepath.Join(buildBaseDir, rel)
+	ess.DeleteFiles(destDir)
+	return createDirArchive(p.OutDir, destDir)
+}
+
+func (p *assetPipeline) processFile(fpath, rel string, info os.FileInfo) error {
+	data, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		return err
+	}
+
+	a := &asset{Path: rel, Ext: normalizeExt(path.Ext(rel)), Data: data}
+	if !p.skips.Match(info.Name()) {
+		for _, step := range p.steps {
+			if err = p.runStep(step, a); err != nil {
+				return fmt.Errorf("Asset pipeline '%s' failed for '%s': %s", step, rel, err)
+			}
+		}
+
+		if p.fingerprint {
+			sum := sha256.Sum256(a.Data)
+			p.Manifest[rel] = fingerprintName(rel, hex.EncodeToString(sum[:])[:p.hashLength])
+			if err = p.writeFile(p.Manifest[rel], a.Data, info); err != nil {
+				return err
+			}
+			if !p.keepOriginal {
+				return nil
+			}
+		}
+	}
+
+	return p.writeFile(rel, a.Data, info)
+}
+
+func (p *assetPipeline) runStep(step string, a *asset) error {
+	if fn, found := assetProcessors[step]; found {
+		return fn(p, a)
+	}
+
+	cmd := p.commands[step]
+	if !cmd.Extensions[a.Ext] {
+		return nil
+	}
+	f, err := ioutil.TempFile("", "aah-asset-*."+a.Ext)
+	if err != nil {
+		return err
+	}
+	defer ess.DeleteFiles(f.Name())
+	if _, err = f.Write(a.Data); err != nil {
+		ess.CloseQuietly(f)
+		return err
+	}
+	ess.CloseQuietly(f)
+
+	args := make([]string, len(cmd.Command)-1)
+	for i, arg := range cmd.Command[1:] {
+		args[i] = strings.Replace(arg, assetFileArg, f.Name(), -1)
+	}
+	if _, err = execCmd(cmd.Command[0], args, false); err != nil {
+		return err
+	}
+	a.Data, err = ioutil.ReadFile(f.Name())
+	return err
+}
+
+// writeFile method writes the asset into staging directory with source file
+// mode and mod time, so VFS embed cache and reproducible build stays intact.
+func (p *assetPipeline) writeFile(rel string, data []byte, info os.FileInfo) error {
+	dest := filepath.Join(p.OutDir, filepath.FromSlash(rel))
+	if err := ioutil.WriteFile(dest, data, info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Chtimes(dest, info.ModTime(), info.ModTime())
+}
+
+// minifyAsset method minifies the asset if its type is enabled via
+// 'build.assets.minify'. Already minified files '*.min.*' are not processed.
+func minifyAsset(p *assetPipeline, a *asset) error {
+	if !p.minifyTypes[a.Ext] || strings.Contains(path.Base(a.Path), ".min.") {
+		return nil
+	}
+	b, err := assetMinifiers[a.Ext](a.Data)
+	if err != nil {
+		cliLog.Warnf("Unable to minify '%s', using as-is: %s", a.Path, err)
+		return nil
+	}
+	a.Data = b
+	return nil
+}
+
+// fingerprintName method adds the hash into file name before extension.
+// For e.g.: 'css/app.css' => 'css/app.3f2a9c1b.css'
+func fingerprintName(rel, hash string) string {
+	ext := path.Ext(rel)
+	return strings.TrimSuffix(rel, ext) + "." + hash + ext
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Asset template func generate
+//___________________________________
+
+// generateAssetFuncSource method generates the template func which resolves
+// asset path to fingerprinted URL. Asset manifest is empty if pipeline has
+// not run, e.g. 'aah run', then asset path resolves as-is.
+func generateAssetFuncSource(appBaseDir string, projectCfg *config.Config) error {
+	if !projectCfg.BoolDefault("build.assets.enable", false) {
+		return nil
+	}
+
+	manifest := make(map[string]string)
+	if assetBuildResult != nil {
+		manifest = assetBuildResult.Manifest
+	}
+	return generateSource(filepath.Join(appBaseDir, "app", "generated"), "aah_assets.go",
+		aahAssetsTemplate, map[string]interface{}{
+			"AahVersion":   strings.TrimPrefix(strings.TrimSpace(aahVer), "v"),
+			"FuncName":     projectCfg.StringDefault("build.assets.template_func", "asset"),
+			"URLPrefix":    strings.TrimRight(projectCfg.StringDefault("build.assets.url_prefix", "/static"), "/"),
+			"Manifest":     manifest,
+			"ManifestKeys": sortedKeys(manifest),
+		})
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+const aahAssetsTemplate = `// Code generated by aah CLI, DO NOT EDIT
+//
+// aah framework v{{ .AahVersion }} - https://aahframework.org
+// FILE: aah_assets.go
+// DESC: aah application asset template func, resolves fingerprinted asset URL
+
+package generated
+
+import (
+	"html/template"
+	"strings"
+
+	"aahframe.work"
+)
+
+var assetManifest = map[string]string{ {{- range $k := .ManifestKeys }}
+	{{ printf "%q" $k }}: {{ printf "%q" (index $.Manifest $k) }},{{ end }}
+}
+
+func init() {
+	aah.App().AddTemplateFunc(template.FuncMap{
+		// {{ .FuncName }} method returns the fingerprinted URL of asset if exists
+		// otherwise asset URL as-is. For e.g.: {{ "{{" }} {{ .FuncName }} "css/app.css" {{ "}}" }}
+		"{{ .FuncName }}": func(p string) string {
+			p = strings.TrimPrefix(p, "/")
+			if fp, found := assetManifest[p]; found {
+				p = fp
+			}
+			return {{ printf "%q" (print .URLPrefix "/") }} + p
+		},
+	})
+}
+`
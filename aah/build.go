@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"aahframe.work"
 	"aahframe.work/config"
@@ -25,22 +26,94 @@ var buildCmd = console.Command{
 	Description: `Builds aah application for deployment. It supports single and non-single
 	binary. It is a trade-off learn more https://docs.aahframework.org/vfs.html
 
+	Single binary build keeps VFS embed cache at '<aahpath>/vfs-cache', unchanged files
+	and mounts are reused from it. Disable it via 'vfs.cache = false' in 'aah.project'.
+	By default files are embedded as Go string literals; set 'vfs.embed_mode = "goembed"'
+	to embed each mount as single data blob via '//go:embed' (Go 1.16+), it reduces
+	generated source size and compile time for large assets.
+
+	Precompressed brotli variant of embedded files are added next to the file as
+	'<file>.br' in VFS via 'vfs.precompress' in 'aah.project', e.g. 'encodings = ["br"]'.
+	Options 'min_size', 'extensions', 'brotli_level' and per-extension 'policy' control
+	which files get the variant.
+	aah static file handler does not serve them by 'Accept-Encoding', lookup package
+	'<app-import-path>/app/generated/precompressed' is generated for the application,
+	e.g. precompressed.Lookup("/app/static/css/app.css", acceptEncoding) returns
+	the variant VFS path and its encoding to serve with 'Content-Encoding' header.
+
+	Mount index for 'aah vfs' command is kept within binary outside of VFS, so it's
+	not served by the application. Disable it via 'vfs.index = false' in 'aah.project'.
+
 	Artifact naming convention:  <appbinaryname>-<appversion>-<goos>-<goarch>.zip
 	For e.g.: aahwebsite-381eaa8-darwin-amd64.zip
 
+	Artifact format can be 'zip' (default), 'tar.gz', 'dir' or 'oci' via '--format'
+	or 'build.format' in 'aah.project'. The 'oci' format creates OCI image layout
+	tarball '<appbinaryname>-<appversion>-<goos>-<goarch>-oci.tar' without Docker daemon,
+	it can be loaded via tools like 'skopeo', 'podman', 'crane', etc.
+
+	Cross compilation targets can be given via '--targets' or 'build.targets' in
+	'aah.project', sources are generated once and each target is compiled in parallel.
+
+	Reproducible build ('--reproducible' or 'build.reproducible' in 'aah.project')
+	honors 'SOURCE_DATE_EPOCH' otherwise last git commit timestamp; it normalizes
+	build timestamp, VFS and archive entry mod times and compiles with '-trimpath'.
+	Use '--verify' to rebuild without VFS cache and compare the artifact checksums.
+
+	Build manifest '<artifact>.build-manifest.json' is created next to the artifact
+	with versions, git commit, target, per-file and artifact SHA-256 checksums. SBOM
+	'<artifact>.spdx.json' (or '.cdx.json') is created from 'go version -m <binary>',
+	format is configured via 'build.sbom' in 'aah.project' as 'spdx' (default),
+	'cyclonedx' or 'none'.
+
+	Asset pipeline ('build.assets' in 'aah.project') minifies CSS, JS, SVG and JSON
+	files of 'static' directory, adds content hash into file names and writes
+	'asset-manifest.json'. Views resolve fingerprinted URL via template func, e.g.
+	{{ asset "css/app.css" }} => /static/css/app.3f2a9c1b.css
+
+	Artifact and build manifest are signed with ed25519 key via '--sign-key', detached
+	signature is written as '<file>.sig'. Use 'aah verify <artifact>' to verify it.
+	SBOM is covered by its checksum recorded in the signed build manifest. Signing
+	is not supported for 'dir' format.
+
 	Example:
 		aah build --single
 		aah build --single --output /Users/jeeva/aahwebsite.zip
-		aah build --output /Users/jeeva/aahwebsite.zip`,
+		aah build --output /Users/jeeva/aahwebsite.zip
+		aah build --single --targets linux/amd64,linux/arm64,windows/amd64
+		aah build --single --format oci
+		aah build --single --reproducible --verify
+		aah build --single --sign-key /path/to/release-key.pem`,
 	Flags: []console.Flag{
 		console.StringFlag{
 			Name:  "output, o",
-			Usage: "Output of aah application build artifact; the default is '<appbasedir>/build/<appbinaryname>-<appversion>-<goos>-<goarch>.zip'",
+			Usage: "Output of aah application build artifact; the default is '<appbasedir>/build/<appbinaryname>-<appversion>-<goos>-<goarch>.zip', for 'dir' format it's parent directory",
 		},
 		console.BoolFlag{
 			Name:  "single, s",
 			Usage: "Creates aah single application binary",
 		},
+		console.StringFlag{
+			Name:  "format, f",
+			Usage: "Build artifact format 'zip', 'tar.gz', 'dir' or 'oci'; the default is 'zip'",
+		},
+		console.StringFlag{
+			Name:  "targets, t",
+			Usage: "Comma separated build targets in the format of 'goos/goarch' e.g. 'linux/amd64,darwin/amd64'",
+		},
+		console.BoolFlag{
+			Name:  "reproducible",
+			Usage: "Creates reproducible build, same source produces identical artifact",
+		},
+		console.StringFlag{
+			Name:   "sign-key",
+			Usage:  "Signs the artifact and build manifest with ed25519 key, file path or 'env:<VAR_NAME>'",
+			EnvVar: "AAH_SIGN_KEY",
+		},
+		console.BoolFlag{
+			Name:  "verify",
+			Usage: "Rebuilds the application and compares the artifact checksums (implies '--reproducible')",
+		},
 	},
 	Action: buildAction,
 }
@@ -67,69 +140,218 @@ func buildAction(c *console.Context) error {
 	cliLog.Infof("Build starts for '%s' [%s]", app.Name(), app.ImportPath())
 	cleanupAutoGenFiles(app.BaseDir())
 
-	if c.Bool("single") {
-		buildSingleBinary(c, projectCfg)
+	targets := buildTargets(c, projectCfg)
+	format := buildArchiveFormat(c, projectCfg)
+	if ext := archiveFormatExts[format]; len(targets) > 1 && !ess.IsStrEmpty(ext) &&
+		strings.HasSuffix(c.String("output"), ext) {
+		logFatalf("Multiple build targets requires '--output' to be a directory")
+	}
+
+	if c.Bool("reproducible") || c.Bool("verify") || projectCfg.BoolDefault("build.reproducible", false) {
+		initReproducibleBuild(app.BaseDir())
+		cliLog.Infof("Reproducible build enabled, epoch: %s", reproducible.Epoch.Format(time.RFC3339))
+	}
+
+	if keyRef := strings.TrimSpace(c.String("sign-key")); !ess.IsStrEmpty(keyRef) {
+		if format == archiveFormatDir {
+			logFatalf("Signing is not supported for artifact format '%s', use 'zip', 'tar.gz' or 'oci'", format)
+		}
+		key, err := loadSignKey(keyRef)
+		if err != nil {
+			logFatal(err)
+		}
+		buildSignKey = key
+	}
+
+	artifacts := buildArtifacts(c, projectCfg, targets, format)
+	if c.Bool("verify") {
+		verifyBuildArtifacts(c, projectCfg, targets, format, artifacts)
+	}
+
+	cliLog.Infof("Build successful for '%s' [%s]", app.Name(), app.ImportPath())
+	if len(artifacts) == 1 {
+		cliLog.Infof("Application artifact is here: %s\n", artifacts[0])
 	} else {
-		buildBinary(c, projectCfg)
+		cliLog.Infof("Application artifacts are here: \n\t%s\n", strings.Join(artifacts, "\n\t"))
 	}
 
 	return nil
 }
 
-func buildBinary(c *console.Context, projectCfg *config.Config) {
+func buildArtifacts(c *console.Context, projectCfg *config.Config, targets []buildTarget, format string) []string {
+	if c.Bool("single") {
+		return buildSingleBinary(c, projectCfg, targets, format)
+	}
+	return buildBinary(c, projectCfg, targets, format)
+}
+
+// verifyBuildArtifacts method rebuilds the application and compares the
+// artifact checksums with the previous build.
+func verifyBuildArtifacts(c *console.Context, projectCfg *config.Config, targets []buildTarget, format string, artifacts []string) {
+	app := aah.App()
+	checksums := make(map[string]string)
+	for _, artifact := range artifacts {
+		sum, err := pathChecksum(artifact)
+		if err != nil {
+			logFatal(err)
+		}
+		checksums[artifact] = sum
+	}
+
+	cliLog.Infof("Verify starts, rebuilding '%s' [%s]", app.Name(), app.ImportPath())
+	cleanupAutoGenFiles(app.BaseDir())
+
+	// rebuild generates VFS sources again, cache would hide nondeterminism
+	vfsCacheBypass = true
+	defer func() { vfsCacheBypass = false }()
+
+	var mismatches []string
+	for _, artifact := range buildArtifacts(c, projectCfg, targets, format) {
+		sum, err := pathChecksum(artifact)
+		if err != nil {
+			logFatal(err)
+		}
+		if checksums[artifact] != sum {
+			mismatches = append(mismatches, fmt.Sprintf("%s\n\t    first:  %s\n\t    second: %s",
+				artifact, checksums[artifact], sum))
+			continue
+		}
+		cliLog.Infof("|-- Verified: %s  %s", sum, filepath.Base(artifact))
+	}
+
+	if len(mismatches) > 0 {
+		logFatalf("Build is not reproducible, artifact checksum mismatch:\n\t%s", strings.Join(mismatches, "\n\t"))
+	}
+	cliLog.Infof("Verify successful, build artifacts are reproducible")
+}
+
+func buildBinary(c *console.Context, projectCfg *config.Config, targets []buildTarget, format string) []string {
 	app := aah.App()
 	appBaseDir := app.BaseDir()
+	processAssetPipeline(projectCfg)
+	defer cleanupAssetPipeline()
 	processVFSConfig(projectCfg, false)
 
-	appBinary, err := compileApp(&compileArgs{
-		Cmd:        "BuildCmd",
-		ProjectCfg: projectCfg,
-		AppPack:    true,
+	buildTimestamp := getBuildTimestamp()
+	binaries, err := compileAppTargets(&compileArgs{
+		Cmd:            "BuildCmd",
+		ProjectCfg:     projectCfg,
+		AppPack:        true,
+		Targets:        targets,
+		BuildTimestamp: buildTimestamp,
 	})
 	if err != nil {
 		logFatal(err)
 	}
 
-	buildBaseDir, err := copyFilesToWorkingDir(projectCfg, appBaseDir, appBinary)
-	if err != nil {
-		logFatal(err)
-	}
+	var artifacts []string
+	for _, b := range binaries {
+		buildBaseDir, err := copyFilesToWorkingDir(projectCfg, appBaseDir, b.Binary)
+		if err != nil {
+			logFatal(err)
+		}
 
-	destArchiveFile := createZipArchiveName(c, projectCfg, appBaseDir, appBinary)
+		destArchiveFile := createArchiveName(c, projectCfg, appBaseDir, b.Binary, b.Target, format)
 
-	// Creating app archive
-	if err = createZipArchive(buildBaseDir, destArchiveFile); err != nil {
-		logFatal(err)
+		// Creating app archive
+		if err = createArchive(format, buildBaseDir, destArchiveFile, b.Target, projectCfg); err != nil {
+			logFatal(err)
+		}
+		artifacts = append(artifacts, destArchiveFile)
+
+		// Build manifest and SBOM
+		metaFiles, err := createBuildManifest(projectCfg, b, buildBaseDir, destArchiveFile, buildTimestamp)
+		if err != nil {
+			logFatal(err)
+		}
+
+		// Signing artifact and build manifest
+		if buildSignKey != nil {
+			if err = signFiles(buildSignKey, destArchiveFile, metaFiles[0]); err != nil {
+				logFatal(err)
+			}
+		}
 	}
 
-	cliLog.Infof("Build successful for '%s' [%s]", app.Name(), app.ImportPath())
-	cliLog.Infof("Application artifact is here: %s\n", destArchiveFile)
+	return artifacts
 }
 
-func buildSingleBinary(c *console.Context, projectCfg *config.Config) {
+func buildSingleBinary(c *console.Context, projectCfg *config.Config, targets []buildTarget, format string) []string {
 	app := aah.App()
+	processAssetPipeline(projectCfg)
+	defer cleanupAssetPipeline()
+
 	cliLog.Infof("Embed starts for '%s' [%s]", app.Name(), app.ImportPath())
 	processVFSConfig(projectCfg, true)
 	cliLog.Infof("Embed successful for '%s' [%s]", app.Name(), app.ImportPath())
 
-	appBinary, err := compileApp(&compileArgs{
-		Cmd:        "BuildCmd",
-		ProjectCfg: projectCfg,
-		AppPack:    true,
-		AppEmbed:   true,
+	buildTimestamp := getBuildTimestamp()
+	binaries, err := compileAppTargets(&compileArgs{
+		Cmd:            "BuildCmd",
+		ProjectCfg:     projectCfg,
+		AppPack:        true,
+		AppEmbed:       true,
+		Targets:        targets,
+		BuildTimestamp: buildTimestamp,
 	})
 	if err != nil {
 		logFatal(err)
 	}
 
 	// Creating app archive
-	destArchiveFile := createZipArchiveName(c, projectCfg, app.BaseDir(), appBinary)
-	if err = createZipArchive(appBinary, destArchiveFile); err != nil {
+	var artifacts []string
+	for _, b := range binaries {
+		destArchiveFile := createArchiveName(c, projectCfg, app.BaseDir(), b.Binary, b.Target, format)
+		if err = createArchive(format, b.Binary, destArchiveFile, b.Target, projectCfg); err != nil {
+			logFatal(err)
+		}
+		artifacts = append(artifacts, destArchiveFile)
+
+		// Build manifest and SBOM
+		metaFiles, err := createBuildManifest(projectCfg, b, b.Binary, destArchiveFile, buildTimestamp)
+		if err != nil {
+			logFatal(err)
+		}
+
+		// Signing artifact and build manifest
+		if buildSignKey != nil {
+			if err = signFiles(buildSignKey, destArchiveFile, metaFiles[0]); err != nil {
+				logFatal(err)
+			}
+		}
+	}
+
+	return artifacts
+}
+
+// buildTargets method returns the build targets from '--targets' flag
+// otherwise 'build.targets' from 'aah.project'. Returns nil if not
+// configured, it means current GOOS/GOARCH.
+func buildTargets(c *console.Context, projectCfg *config.Config) []buildTarget {
+	values := []string{c.String("targets")}
+	if ess.IsStrEmpty(strings.TrimSpace(values[0])) {
+		values, _ = projectCfg.StringList("build.targets")
+	}
+	targets, err := parseBuildTargets(values)
+	if err != nil {
 		logFatal(err)
 	}
+	return targets
+}
 
-	cliLog.Infof("Build successful for '%s' [%s]", app.Name(), app.ImportPath())
-	cliLog.Infof("Application artifact is here: %s\n", destArchiveFile)
+// goModGoVersion method returns the 'go' directive value of the application
+// 'go.mod' file, empty string if file or directive does not exist.
+func goModGoVersion(appBaseDir string) string {
+	b, err := ioutil.ReadFile(filepath.Join(appBaseDir, goModIdentifier))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		if fields := strings.Fields(line); len(fields) >= 2 && fields[0] == "go" {
+			return fields[1]
+		}
+	}
+	return ""
 }
 
 func processVFSConfig(projectCfg *config.Config, mode bool) {
@@ -137,9 +359,44 @@ func processVFSConfig(projectCfg *config.Config, mode bool) {
 	excludes, _ := projectCfg.StringList("build.excludes")
 	noGzipList, _ := projectCfg.StringList("vfs.no_gzip")
 
+	embedMode := strings.ToLower(projectCfg.StringDefault("vfs.embed_mode", vfsEmbedModeString))
+	switch embedMode {
+	case vfsEmbedModeString:
+	case vfsEmbedModeGoEmbed:
+		if !mode {
+			break
+		}
+		if !inferGoVersionAtLeast(1, 16) {
+			logFatalf("vfs.embed_mode '%s' requires Go 1.16 or above, found go%s", embedMode, goVersion())
+		}
+		// go:embed directive is honored only if the module language version is 1.16+
+		if ver := goModGoVersion(appBaseDir); !ess.IsStrEmpty(ver) && !isGoVersionAtLeast(ver, 1, 16) {
+			logFatalf("vfs.embed_mode '%s' requires 'go 1.16' or above directive in the application '%s', found 'go %s'",
+				embedMode, goModIdentifier, ver)
+		}
+	default:
+		logFatalf("Unsupported vfs.embed_mode '%s', supported modes are '%s', '%s'",
+			embedMode, vfsEmbedModeString, vfsEmbedModeGoEmbed)
+	}
+
+	if mode {
+		var err error
+		if vfsPrecompressPolicy, err = newVFSPrecompress(projectCfg); err != nil {
+			logFatal(err)
+		}
+		vfsIndexEnabled = projectCfg.BoolDefault("vfs.index", true)
+	}
+	vfsVariants = nil
+
+	vfsCacheStore = nil
+	if mode && !vfsCacheBypass && projectCfg.BoolDefault("vfs.cache", true) {
+		vfsCacheStore = newVFSCache(appBaseDir)
+		defer vfsCacheStore.Prune()
+	}
+
 	if mode {
 		// Default mount point
-		if err := processMount(mode, appBaseDir, "/app", appBaseDir, ess.Excludes(excludes), noGzipList); err != nil {
+		if err := processMount(mode, appBaseDir, "/app", appBaseDir, ess.Excludes(excludes), noGzipList, embedMode); err != nil {
 			logFatal(err)
 		}
 	}
@@ -156,7 +413,7 @@ func processVFSConfig(projectCfg *config.Config, mode bool) {
 		}
 
 		if !ess.IsStrEmpty(vroot) && !ess.IsStrEmpty(proot) {
-			if err := processMount(mode, appBaseDir, vroot, proot, ess.Excludes(excludes), noGzipList); err != nil {
+			if err := processMount(mode, appBaseDir, vroot, proot, ess.Excludes(excludes), noGzipList, embedMode); err != nil {
 				logError(err)
 			}
 		}
@@ -210,24 +467,30 @@ func copyFilesToWorkingDir(projectCfg *config.Config, appBaseDir, appBinary stri
 		}
 	}
 
-	return buildBaseDir, err
-}
-
-func createZipArchive(buildBaseDir, destArchiveFile string) error {
-	ess.DeleteFiles(destArchiveFile)
-
-	archiveBaseDir := filepath.Dir(destArchiveFile)
-	if err := ess.MkDirAll(archiveBaseDir, permRWXRXRX); err != nil {
-		return err
+	// assets directory goes from asset pipeline output
+	if assetBuildResult != nil {
+		if err = assetBuildResult.ReplaceDir(appBaseDir, buildBaseDir); err != nil {
+			return "", err
+		}
 	}
-	return ess.Zip(destArchiveFile, buildBaseDir)
+
+	return buildBaseDir, err
 }
 
-func createZipArchiveName(c *console.Context, projectCfg *config.Config, appBaseDir, appBinary string) string {
+func createArchiveName(c *console.Context, projectCfg *config.Config, appBaseDir, appBinary string, target buildTarget, format string) string {
 	var err error
 	outputFile := c.String("output")
-	archiveName := ess.StripExt(filepath.Base(appBinary)) + "-" + getAppVersion(appBaseDir, projectCfg)
-	archiveName = addTargetBuildInfo(archiveName)
+	archiveName := strings.TrimSuffix(filepath.Base(appBinary), ".exe") + "-" + getAppVersion(appBaseDir, projectCfg)
+	archiveName = addTargetBuildInfo(archiveName, target)
+	ext := archiveFormatExts[format]
+
+	// 'dir' format, output is the parent directory
+	if ess.IsStrEmpty(ext) {
+		if ess.IsStrEmpty(outputFile) {
+			return filepath.Join(appBaseDir, "build", archiveName)
+		}
+		return filepath.Join(absPath(outputFile), archiveName)
+	}
 
 	var destArchiveFile string
 	if ess.IsStrEmpty(outputFile) {
@@ -238,13 +501,13 @@ func createZipArchiveName(c *console.Context, projectCfg *config.Config, appBase
 			logFatal(err)
 		}
 
-		if !strings.HasSuffix(destArchiveFile, ".zip") {
+		if !strings.HasSuffix(destArchiveFile, ext) {
 			destArchiveFile = filepath.Join(destArchiveFile, archiveName)
 		}
 	}
 
-	if !strings.HasSuffix(destArchiveFile, ".zip") {
-		destArchiveFile = destArchiveFile + ".zip"
+	if !strings.HasSuffix(destArchiveFile, ext) {
+		destArchiveFile = destArchiveFile + ext
 	}
 	return destArchiveFile
 }
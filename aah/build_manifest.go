@@ -0,0 +1,367 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"aahframe.work"
+	"aahframe.work/config"
+	"aahframe.work/essentials"
+)
+
+const (
+	buildManifestSuffix = ".build-manifest.json"
+	sbomFormatSPDX      = "spdx"
+	sbomFormatCycloneDX = "cyclonedx"
+	sbomFormatNone      = "none"
+)
+
+// buildManifest struct holds the build information of an artifact, it's
+// written as '<artifact>.build-manifest.json' next to the artifact.
+type buildManifest struct {
+	Name           string               `json:"name"`
+	ImportPath     string               `json:"import_path"`
+	Version        string               `json:"version"`
+	BuildTimestamp string               `json:"build_timestamp"`
+	GoVersion      string               `json:"go_version"`
+	AahVersion     string               `json:"aah_version"`
+	CLIVersion     string               `json:"cli_version"`
+	GitCommit      string               `json:"git_commit,omitempty"`
+	OS             string               `json:"os"`
+	Arch           string               `json:"arch"`
+	Reproducible   bool                 `json:"reproducible"`
+	Artifact       buildManifestFile    `json:"artifact"`
+	SBOM           *buildManifestFile   `json:"sbom,omitempty"`
+	Files          []*buildManifestFile `json:"files"`
+}
+
+type buildManifestFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size,omitempty"`
+	Mode   string `json:"mode,omitempty"`
+	SHA256 string `json:"sha256"`
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Build manifest methods
+//___________________________________
+
+// createBuildManifest method writes the build manifest and SBOM (if enabled)
+// next to the artifact. The 'src' is the packaged directory or single binary,
+// each file within it is listed with SHA-256 checksum.
+func createBuildManifest(projectCfg *config.Config, b *targetBinary, src, artifact, buildTimestamp string) ([]string, error) {
+	app := aah.App()
+	m := &buildManifest{
+		Name:           app.Name(),
+		ImportPath:     app.ImportPath(),
+		Version:        getAppVersion(app.BaseDir(), projectCfg),
+		BuildTimestamp: buildTimestamp,
+		GoVersion:      goVersion(),
+		AahVersion:     strings.TrimPrefix(strings.TrimSpace(aahVer), "v"),
+		CLIVersion:     Version,
+		GitCommit:      gitCommit(app.BaseDir()),
+		OS:             b.Target.GOOS,
+		Arch:           b.Target.GOARCH,
+		Reproducible:   reproducible.Enabled,
+	}
+
+	var err error
+	if m.Files, err = manifestFiles(src); err != nil {
+		return nil, err
+	}
+
+	artifactSum, err := pathChecksum(artifact)
+	if err != nil {
+		return nil, err
+	}
+	m.Artifact = buildManifestFile{Path: filepath.Base(artifact), SHA256: artifactSum}
+	if fi, err := os.Stat(artifact); err == nil && !fi.IsDir() {
+		m.Artifact.Size = fi.Size()
+	}
+
+	var files []string
+	sbomFormat := strings.ToLower(projectCfg.StringDefault("build.sbom", sbomFormatSPDX))
+	if mods := sbomModules(sbomFormat, b.Binary); len(mods) > 0 {
+		sbomFile, err := createSBOM(sbomFormat, artifact, m, mods)
+		if err != nil {
+			return nil, err
+		}
+		sbomSum, err := pathChecksum(sbomFile)
+		if err != nil {
+			return nil, err
+		}
+		m.SBOM = &buildManifestFile{Path: filepath.Base(sbomFile), SHA256: sbomSum}
+		files = append(files, sbomFile)
+	}
+
+	manifestFile := artifactSidecarName(artifact) + buildManifestSuffix
+	if err = writeJSONFile(manifestFile, m); err != nil {
+		return nil, err
+	}
+	return append([]string{manifestFile}, files...), nil
+}
+
+// manifestFiles method returns the files of given directory or file with
+// its SHA-256 checksum, sorted by path.
+func manifestFiles(src string) ([]*buildManifestFile, error) {
+	var files []*buildManifestFile
+	baseDir := filepath.Dir(src)
+	err := filepath.Walk(src, func(fpath string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		sum, err := pathChecksum(fpath)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(baseDir, fpath)
+		files = append(files, &buildManifestFile{
+			Path:   filepath.ToSlash(rel),
+			Size:   info.Size(),
+			Mode:   info.Mode().String(),
+			SHA256: sum,
+		})
+		return nil
+	})
+	return files, err
+}
+
+// artifactSidecarName method returns the artifact path without archive
+// extension, used as prefix of the files written next to the artifact.
+func artifactSidecarName(artifact string) string {
+	for _, ext := range archiveFormatExts {
+		if !ess.IsStrEmpty(ext) && strings.HasSuffix(artifact, ext) {
+			return strings.TrimSuffix(artifact, ext)
+		}
+	}
+	return artifact
+}
+
+func gitCommit(appBaseDir string) string {
+	if !ess.IsFileExists(filepath.Join(appBaseDir, ".git")) {
+		return ""
+	}
+	output, err := execCmd(gitcmd, []string{"-C", appBaseDir, "rev-parse", "HEAD"}, false)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(output)
+}
+
+func sbomModules(format, binary string) []*module {
+	if format == sbomFormatNone {
+		return nil
+	}
+	return binaryModules(binary)
+}
+
+// binaryModules method returns application module and its dependencies
+// compiled into the binary via 'go version -m <binary>', so SBOM lists only
+// the modules linked into the artifact for its target.
+func binaryModules(binary string) []*module {
+	output, err := execCmd(gocmd, []string{"version", "-m", binary}, false)
+	if err != nil {
+		logErrorf("Unable to read modules from binary '%s' for SBOM: %s", binary, err)
+		return nil
+	}
+	return parseGoVersionModOutput(output)
+}
+
+// parseGoVersionModOutput method parses the module lines of
+// 'go version -m' output. Main module is the first item, replaced module is
+// reported with replacement path and version.
+func parseGoVersionModOutput(output string) []*module {
+	var mods []*module
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		if len(fields) < 2 {
+			continue
+		}
+		version := ""
+		if len(fields) > 2 && fields[2] != "(devel)" {
+			version = fields[2]
+		}
+		switch fields[0] {
+		case "mod":
+			mods = append([]*module{{Path: fields[1], Version: version, Main: true}}, mods...)
+		case "dep":
+			mods = append(mods, &module{Path: fields[1], Version: version})
+		case "=>":
+			if len(mods) > 0 && !ess.IsStrEmpty(version) {
+				mods[len(mods)-1].Path, mods[len(mods)-1].Version = fields[1], version
+			}
+		}
+	}
+	return mods
+}
+
+func writeJSONFile(file string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, append(b, '\n'), permRWRWRW)
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// SBOM methods
+//___________________________________
+
+func createSBOM(format, artifact string, m *buildManifest, mods []*module) (string, error) {
+	var (
+		sbom interface{}
+		ext  string
+	)
+	switch format {
+	case sbomFormatSPDX:
+		sbom, ext = spdxDocument(m, mods), ".spdx.json"
+	case sbomFormatCycloneDX:
+		sbom, ext = cycloneDXDocument(m, mods), ".cdx.json"
+	default:
+		return "", fmt.Errorf("Unsupported SBOM format '%s', supported formats are 'spdx', 'cyclonedx', 'none'", format)
+	}
+
+	sbomFile := artifactSidecarName(artifact) + ext
+	return sbomFile, writeJSONFile(sbomFile, sbom)
+}
+
+func modulePurl(path, version string) string {
+	purl := "pkg:golang/" + path
+	if !ess.IsStrEmpty(version) {
+		purl += "@" + version
+	}
+	return purl
+}
+
+func sbomTimestamp(m *buildManifest) string {
+	if t, err := time.Parse(time.RFC3339, m.BuildTimestamp); err == nil {
+		return t.UTC().Format(time.RFC3339)
+	}
+	return modTime(time.Now()).UTC().Format(time.RFC3339)
+}
+
+// moduleVersion method returns the module version, main module version is
+// the application version.
+func moduleVersion(m *buildManifest, mod *module) string {
+	if mod.Main || ess.IsStrEmpty(mod.Version) {
+		return m.Version
+	}
+	return mod.Version
+}
+
+// spdxDocument method creates SPDX 2.3 JSON document.
+func spdxDocument(m *buildManifest, mods []*module) map[string]interface{} {
+	var packages, relationships []map[string]interface{}
+	for i, mod := range mods {
+		version := moduleVersion(m, mod)
+		id := fmt.Sprintf("SPDXRef-Package-%d", i)
+		packages = append(packages, map[string]interface{}{
+			"name":             mod.Path,
+			"SPDXID":           id,
+			"versionInfo":      version,
+			"downloadLocation": "NOASSERTION",
+			"filesAnalyzed":    false,
+			"externalRefs": []map[string]string{{
+				"referenceCategory": "PACKAGE-MANAGER",
+				"referenceType":     "purl",
+				"referenceLocator":  modulePurl(mod.Path, version),
+			}},
+		})
+		if i == 0 {
+			relationships = append(relationships, map[string]interface{}{
+				"spdxElementId":      "SPDXRef-DOCUMENT",
+				"relationshipType":   "DESCRIBES",
+				"relatedSpdxElement": id,
+			})
+		} else {
+			relationships = append(relationships, map[string]interface{}{
+				"spdxElementId":      "SPDXRef-Package-0",
+				"relationshipType":   "DEPENDS_ON",
+				"relatedSpdxElement": id,
+			})
+		}
+	}
+
+	docName := m.Name + "-" + m.Version + "-" + m.OS + "-" + m.Arch
+	return map[string]interface{}{
+		"spdxVersion":       "SPDX-2.3",
+		"dataLicense":       "CC0-1.0",
+		"SPDXID":            "SPDXRef-DOCUMENT",
+		"name":              docName,
+		"documentNamespace": "https://aahframework.org/spdx/" + docName + "-" + m.Artifact.SHA256,
+		"creationInfo": map[string]interface{}{
+			"created":  sbomTimestamp(m),
+			"creators": []string{"Tool: aah-cli-" + m.CLIVersion},
+		},
+		"packages":      packages,
+		"relationships": relationships,
+	}
+}
+
+// cycloneDXDocument method creates CycloneDX 1.4 JSON document.
+func cycloneDXDocument(m *buildManifest, mods []*module) map[string]interface{} {
+	var (
+		components []map[string]interface{}
+		dependsOn  []string
+		mainRef    string
+	)
+	for i, mod := range mods {
+		version := moduleVersion(m, mod)
+		purl := modulePurl(mod.Path, version)
+		if i == 0 {
+			mainRef = purl
+			continue
+		}
+		components = append(components, map[string]interface{}{
+			"type":    "library",
+			"bom-ref": purl,
+			"name":    mod.Path,
+			"version": version,
+			"purl":    purl,
+		})
+		dependsOn = append(dependsOn, purl)
+	}
+
+	// serial number is derived from artifact checksum for stable output
+	sum := sha256.Sum256([]byte(m.Artifact.SHA256))
+	h := hex.EncodeToString(sum[:16])
+	serial := fmt.Sprintf("urn:uuid:%s-%s-4%s-a%s-%s", h[:8], h[8:12], h[13:16], h[17:20], h[20:32])
+
+	return map[string]interface{}{
+		"bomFormat":    "CycloneDX",
+		"specVersion":  "1.4",
+		"version":      1,
+		"serialNumber": serial,
+		"metadata": map[string]interface{}{
+			"timestamp": sbomTimestamp(m),
+			"tools": []map[string]string{{
+				"vendor":  "aah framework",
+				"name":    "aah-cli",
+				"version": m.CLIVersion,
+			}},
+			"component": map[string]interface{}{
+				"type":    "application",
+				"bom-ref": mainRef,
+				"name":    mods[0].Path,
+				"version": moduleVersion(m, mods[0]),
+				"purl":    mainRef,
+			},
+		},
+		"components": components,
+		"dependencies": []map[string]interface{}{{
+			"ref":       mainRef,
+			"dependsOn": dependsOn,
+		}},
+	}
+}
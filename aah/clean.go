@@ -20,10 +20,18 @@ var cleanCmd = console.Command{
 	Usage:   "Cleans the aah generated files and build directory",
 	Description: `Cleans the aah generated files and build directory.
 
-	Such as aah.go, '<app-base-dir>/generated' and '<app-base-dir>/build'.
+	Such as aah.go, '<app-base-dir>/generated' and '<app-base-dir>/build'. Use '--cache'
+	to clean the VFS embed cache of the application too.
 
 	Example:
-		aah clean`,
+		aah clean
+		aah clean --cache`,
+	Flags: []console.Flag{
+		console.BoolFlag{
+			Name:  "cache",
+			Usage: "Cleans the VFS embed cache of the application",
+		},
+	},
 	Action: cleanAction,
 }
 
@@ -43,6 +51,10 @@ func cleanAction(c *console.Context) error {
 	projectCfg := aahProjectCfg(app.BaseDir())
 	cliLog = initCLILogger(projectCfg)
 	cleanupAutoGenFiles(app.BaseDir())
+	if c.Bool("cache") {
+		cliLog.Debugf("Cleaning vfs cache directory %s", vfsCacheDir(app.BaseDir()))
+		ess.DeleteFiles(vfsCacheDir(app.BaseDir()))
+	}
 	cliLog.Infof("Import Path '%v' clean successful.\n", importPath)
 	return nil
 }
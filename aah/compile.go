@@ -10,11 +10,14 @@ import (
 	"fmt"
 	"go/format"
 	"io/ioutil"
+	"os"
 	"os/exec"
 	"path"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strings"
+	"sync"
 
 	"aahframe.work"
 	"aahframe.work/ainsp"
@@ -29,6 +32,16 @@ type compileArgs struct {
 	ProjectCfg *config.Config
 	AppPack    bool
 	AppEmbed   bool
+	Targets    []buildTarget
+
+	// BuildTimestamp is used if given otherwise 'getBuildTimestamp'
+	BuildTimestamp string
+}
+
+// targetBinary struct holds the compiled application binary of build target.
+type targetBinary struct {
+	Target buildTarget
+	Binary string
 }
 
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
@@ -38,6 +51,17 @@ type compileArgs struct {
 // compileApp method calls Go ast parser, generates main.go and builds aah
 // application binary at Go bin directory
 func compileApp(args *compileArgs) (string, error) {
+	binaries, err := compileAppTargets(args)
+	if err != nil {
+		return "", err
+	}
+	return binaries[0].Binary, nil
+}
+
+// compileAppTargets method generates the application sources once and builds
+// the binary for each build target in parallel. If no targets are given then
+// it builds for the current GOOS/GOARCH.
+func compileAppTargets(args *compileArgs) ([]*targetBinary, error) {
 	projectCfg := args.ProjectCfg
 
 	// app variables
@@ -66,7 +90,7 @@ func compileApp(args *compileArgs) (string, error) {
 			for _, e := range errs {
 				errMsgs = append(errMsgs, e.Error())
 			}
-			return "", errors.New(strings.Join(errMsgs, "\n"))
+			return nil, errors.New(strings.Join(errMsgs, "\n"))
 		}
 
 		// Print router configuration missing/error details
@@ -100,7 +124,7 @@ func compileApp(args *compileArgs) (string, error) {
 			for _, e := range errs {
 				errMsgs = append(errMsgs, e.Error())
 			}
-			return "", errors.New(strings.Join(errMsgs, "\n"))
+			return nil, errors.New(strings.Join(errMsgs, "\n"))
 		}
 
 		// Print router configuration missing/error details
@@ -122,7 +146,7 @@ func compileApp(args *compileArgs) (string, error) {
 	appImportPaths = wsc.CreateImportPaths(appWebSockets, appImportPaths)
 
 	if len(appControllers) == 0 && len(appWebSockets) == 0 {
-		return "", fmt.Errorf("It seems your application have zero controller or websocket")
+		return nil, fmt.Errorf("It seems your application have zero controller or websocket")
 	}
 
 	if len(appControllers) > 0 || len(appWebSockets) > 0 {
@@ -131,7 +155,10 @@ func compileApp(args *compileArgs) (string, error) {
 
 	// prepare aah application version and build date
 	appVersion := getAppVersion(appBaseDir, projectCfg)
-	appBuildTimestamp := getBuildTimestamp()
+	appBuildTimestamp := args.BuildTimestamp
+	if ess.IsStrEmpty(appBuildTimestamp) {
+		appBuildTimestamp = getBuildTimestamp()
+	}
 
 	// create go build arguments
 	buildArgs := []string{"build"}
@@ -148,12 +175,36 @@ func compileApp(args *compileArgs) (string, error) {
 		buildArgs = append(buildArgs, "-tags", tags)
 	}
 
-	appBinary := appBinaryFile(projectCfg, appBuildDir)
-	appBinaryName := filepath.Base(appBinary)
-	buildArgs = append(buildArgs, "-o", appBinary)
+	if reproducible.Enabled {
+		if inferGoVersionAtLeast(1, 13) {
+			buildArgs = append(buildArgs, "-trimpath")
+		} else {
+			cliLog.Warnf("Go version go%s does not support '-trimpath', build paths are retained in the binary", goVersion())
+		}
+	}
 
-	// main.go location e.g. path/to/import/app
-	buildArgs = append(buildArgs, path.Join(appImportPath, "app"))
+	var binaries []*targetBinary
+	if len(args.Targets) == 0 {
+		target := hostBuildTarget()
+		binaries = append(binaries, &targetBinary{
+			Target: target,
+			Binary: appBinaryFile(projectCfg, appBuildDir, target.GOOS),
+		})
+	} else {
+		for _, target := range args.Targets {
+			binaries = append(binaries, &targetBinary{
+				Target: target,
+				Binary: appBinaryFile(projectCfg, filepath.Join(appBuildDir, target.GOOS+"_"+target.GOARCH), target.GOOS),
+			})
+		}
+	}
+
+	// generated source is shared across targets, so binary name goes without
+	// target specific suffix on cross compilation
+	appBinaryName := filepath.Base(binaries[0].Binary)
+	if len(args.Targets) > 0 {
+		appBinaryName = strings.TrimSuffix(appBinaryName, ".exe")
+	}
 
 	if err := generateSource(filepath.Join(appBaseDir, "app", "generated"), "add_controllers.go",
 		aahControllerTemplate, map[string]interface{}{
@@ -170,7 +221,15 @@ func compileApp(args *compileArgs) (string, error) {
 			"AppIsPackaged":     args.AppPack,
 			"AppIsEmbedded":     args.AppEmbed,
 		}); err != nil {
-		return "", err
+		return nil, err
+	}
+
+	if err := generateAssetFuncSource(appBaseDir, projectCfg); err != nil {
+		return nil, err
+	}
+
+	if err := generateVFSVariantsSource(appBaseDir, projectCfg); err != nil {
+		return nil, err
 	}
 
 	if err := generateSource(filepath.Join(appBaseDir, "app"), "aah.go", aahMainTemplate,
@@ -178,22 +237,74 @@ func compileApp(args *compileArgs) (string, error) {
 			"AahVersion":    strings.TrimPrefix(strings.TrimSpace(aahVer), "v"),
 			"AppImportPath": appImportPath,
 		}); err != nil {
-		return "", err
+		return nil, err
 	}
 
 	// getting project dependencies if not exists in $GOPATH
 	if err := checkAndGetAppDeps(appImportPath, projectCfg); err != nil {
-		return "", fmt.Errorf("unable to get application dependencies: %s", err)
+		return nil, fmt.Errorf("unable to get application dependencies: %s", err)
 	}
 
+	// main.go location e.g. path/to/import/app
+	appMainPkg := path.Join(appImportPath, "app")
+
 	// execute aah applictaion build
-	if _, err := execCmd(gocmd, buildArgs, false); err != nil {
-		return "", err
+	if len(args.Targets) == 0 {
+		buildArgs = append(buildArgs, "-o", binaries[0].Binary, appMainPkg)
+		if _, err := execCmd(gocmd, buildArgs, false); err != nil {
+			return nil, err
+		}
+	} else if err := goBuildTargets(buildArgs, appMainPkg, binaries); err != nil {
+		return nil, err
 	}
 
 	cliLog.Infof("Compile successful for '%s' [%s]", appName, appImportPath)
 
-	return appBinary, nil
+	return binaries, nil
+}
+
+// goBuildTargets method compiles the application for each target in parallel,
+// bounded by number of CPUs.
+func goBuildTargets(buildArgs []string, appMainPkg string, binaries []*targetBinary) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(binaries))
+	sem := make(chan struct{}, runtime.NumCPU())
+	for i, b := range binaries {
+		wg.Add(1)
+		go func(i int, b *targetBinary) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			errs[i] = goBuildTarget(buildArgs, appMainPkg, b)
+		}(i, b)
+	}
+	wg.Wait()
+
+	var errMsgs []string
+	for _, err := range errs {
+		if err != nil {
+			errMsgs = append(errMsgs, err.Error())
+		}
+	}
+	if len(errMsgs) > 0 {
+		return errors.New(strings.Join(errMsgs, "\n"))
+	}
+	return nil
+}
+
+func goBuildTarget(buildArgs []string, appMainPkg string, b *targetBinary) error {
+	args := append(append([]string{}, buildArgs...), "-o", b.Binary, appMainPkg)
+	cmd := exec.Command(gocmd, args...) // #nosec
+	cmd.Env = append(os.Environ(), "GOOS="+b.Target.GOOS, "GOARCH="+b.Target.GOARCH)
+	if _, found := os.LookupEnv("CGO_ENABLED"); !found && b.Target != hostBuildTarget() {
+		cmd.Env = append(cmd.Env, "CGO_ENABLED=0")
+	}
+
+	cliLog.Infof("Compiling target %s", b.Target)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("Unable to compile target %s:\n%s\n%s", b.Target, string(output), err)
+	}
+	return nil
 }
 
 func generateSource(dir, filename, templateSource string, templateArgs map[string]interface{}) error {
@@ -7,6 +7,7 @@ package main
 import (
 	"bytes"
 	"compress/gzip"
+	"encoding/json"
 	"fmt"
 	"go/format"
 	"io"
@@ -14,6 +15,8 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 	"time"
@@ -29,7 +32,18 @@ var defaultGzipMinSize int64 = 1400
 
 var vfsTmpl = template.Must(template.New("vfs").Funcs(vfsTmplFuncMap).Parse(vfsTmplStr))
 
-func processMount(mode bool, appBaseDir, vroot, proot string, skipList ess.Excludes, noGzipList []string) error {
+// VFS embed modes, configured via 'vfs.embed_mode' in 'aah.project'.
+//
+// 'string' embeds file bytes as hex escaped Go string literal.
+// 'goembed' writes file bytes of the mount into single data blob which is
+// embedded via '//go:embed' (requires Go 1.16 or above) and files are added
+// into VFS with offset table of the blob.
+const (
+	vfsEmbedModeString  = "string"
+	vfsEmbedModeGoEmbed = "goembed"
+)
+
+func processMount(mode bool, appBaseDir, vroot, proot string, skipList ess.Excludes, noGzipList []string, embedMode string) error {
 	proot = filepath.ToSlash(proot)
 	if !ess.IsFileExists(proot) {
 		return &os.PathError{Op: "open", Path: proot, Err: os.ErrNotExist}
@@ -38,7 +52,7 @@ func processMount(mode bool, appBaseDir, vroot, proot string, skipList ess.Exclu
 	if mode {
 		cliLog.Infof("|-- Processing mount: '%s' <== '%s'", vroot, proot)
 	}
-	b, err := generateVFSSource(mode, appBaseDir, vroot, proot, skipList, noGzipList)
+	b, blob, err := generateVFSSource(mode, appBaseDir, vroot, proot, skipList, noGzipList, embedMode)
 	if err != nil {
 		return err
 	}
@@ -47,16 +61,38 @@ func processMount(mode bool, appBaseDir, vroot, proot string, skipList ess.Exclu
 	filename := fmt.Sprintf("aah%s_vfs.go", strings.Replace(vroot, "/", "_", -1))
 	absFilepath := filepath.Join(appBaseDir, "app", "generated", filename)
 	_ = ess.MkDirAll(filepath.Dir(absFilepath), permRWXRXRX)
+	ess.DeleteFiles(vfsBlobFile(absFilepath))
+	if blob != nil {
+		if err = ioutil.WriteFile(vfsBlobFile(absFilepath), blob, permRWRWRW); err != nil {
+			return err
+		}
+	}
 	return ioutil.WriteFile(absFilepath, b, permRWXRXRX)
 }
 
+// vfsBlobFile method returns the data blob file name of VFS source file.
+func vfsBlobFile(vfsFile string) string {
+	return strings.TrimSuffix(vfsFile, ".go") + ".blob"
+}
+
 // generateVFSSource method creates Virtual FileSystem (VFS) code
 // to add files and directories within binary for configured Mount points
 // on file aah.project.
-func generateVFSSource(mode bool, appBaseDir, vroot, proot string, skipList ess.Excludes, noGzipList []string) ([]byte, error) {
+//
+// In 'goembed' embed mode, it returns the data blob of the mount as well.
+func generateVFSSource(mode bool, appBaseDir, vroot, proot string, skipList ess.Excludes, noGzipList []string, embedMode string) ([]byte, []byte, error) {
 	err := skipList.Validate()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	var blob *bytes.Buffer
+	varSuffix := toExportedName(strings.Replace(strings.Trim(vroot, "/"), "/", "_", -1))
+	blobFile, blobVar := "", ""
+	if mode && embedMode == vfsEmbedModeGoEmbed {
+		blob = &bytes.Buffer{}
+		blobFile = vfsBlobFile(fmt.Sprintf("aah%s_vfs.go", strings.Replace(vroot, "/", "_", -1)))
+		blobVar = "vfsBlob" + varSuffix
 	}
 
 	buf := &bytes.Buffer{}
@@ -68,17 +104,24 @@ func generateVFSSource(mode bool, appBaseDir, vroot, proot string, skipList ess.
 		"Mode":         mode,
 		"MountPath":    vroot,
 		"PhysicalPath": proot,
+		"BlobFile":     blobFile,
+		"BlobVar":      blobVar,
+		"Index":        mode && vfsIndexEnabled,
 	}); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// non-single binary mode, exit here
 	if !mode {
 		_s(fmt.Fprint(buf, "\n}"))
-		return format.Source(buf.Bytes())
+		b, err := format.Source(buf.Bytes())
+		return b, nil, err
 	}
 
+	var dirs []string
+	dirInfos := make(map[string]os.FileInfo)
 	files := make(map[string]os.FileInfo)
+	srcFiles := make(map[string]string) // asset pipeline output files
 	if err := ess.Walk(proot, func(fpath string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -100,26 +143,142 @@ func generateVFSSource(mode bool, appBaseDir, vroot, proot string, skipList ess.
 		}
 	sc:
 
-		if info.IsDir() {
-			mp := filepath.ToSlash(filepath.Join(vroot, strings.TrimPrefix(fpath, proot)))
-
-			if err = vfsTmpl.ExecuteTemplate(buf, "vfs_dir", aah.Data{
-				"Node": &vfs.NodeInfo{Dir: info.IsDir(), Path: mp, Time: info.ModTime()},
+		// assets directory is replaced with asset pipeline output
+		if info.IsDir() && assetBuildResult != nil && fpath == filepath.ToSlash(assetBuildResult.SrcDir) {
+			outDir := assetBuildResult.OutDir
+			if err := filepath.Walk(outDir, func(opath string, oinfo os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				rel, _ := filepath.Rel(outDir, opath)
+				lpath := path.Join(fpath, filepath.ToSlash(rel))
+				if oinfo.IsDir() {
+					dirs = append(dirs, lpath)
+					dirInfos[lpath] = oinfo
+				} else {
+					files[lpath] = oinfo
+					srcFiles[lpath] = opath
+				}
+				return nil
 			}); err != nil {
 				return err
 			}
+			return filepath.SkipDir
+		}
+
+		if info.IsDir() {
+			dirs = append(dirs, fpath)
+			dirInfos[fpath] = info
 		} else {
 			files[fpath] = info
 		}
 
 		return nil
 	}); err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	// sorted for stable generated source
+	fnames := make([]string, 0, len(files))
+	for fname := range files {
+		fnames = append(fnames, fname)
+	}
+	sort.Strings(fnames)
+
+	// unchanged mount, reuse the generated source from cache
+	var fingerprint string
+	if vfsCacheStore != nil {
+		var dirEntries []string
+		for _, d := range dirs {
+			dirEntries = append(dirEntries, fmt.Sprintf("%s:%d", d, dirInfos[d].ModTime().UnixNano()))
+		}
+		fingerprint = vfsMountFingerprint(vroot, proot, embedMode, noGzipList, dirEntries, files, fnames)
+		if b, blobBytes, found := vfsCacheStore.MountSource(vroot, fingerprint); found {
+			if variants, found := vfsCacheStore.MountVariants(vroot); found {
+				cliLog.Infof("     |-- Unchanged, using cache")
+				addVFSVariants(variants)
+				return b, blobBytes, nil
+			}
+		}
+	}
+
+	// mount index for 'aah vfs' command, physical path is relative to
+	// application base directory
+	physical := proot
+	if rel, err := filepath.Rel(appBaseDir, proot); err == nil {
+		physical = filepath.ToSlash(rel)
+	}
+	idx := &vfsIndex{
+		Version:   1,
+		Mount:     vroot,
+		Physical:  physical,
+		EmbedMode: embedMode,
+		Excludes:  []string(skipList),
+		NoGzip:    noGzipList,
+	}
+
+	// precompressed variant encodings by VFS path of the mount
+	mountVariants := make(map[string][]string)
+
+	for _, d := range dirs {
+		mp := filepath.ToSlash(filepath.Join(vroot, strings.TrimPrefix(d, proot)))
+		node := &vfs.NodeInfo{Dir: true, Path: mp, Time: modTime(dirInfos[d].ModTime())}
+		if err = vfsTmpl.ExecuteTemplate(buf, "vfs_dir", aah.Data{
+			"Node": node,
+		}); err != nil {
+			return nil, nil, err
+		}
+		idx.Dirs = append(idx.Dirs, &vfsIndexEntry{Path: mp, Time: node.Time})
+	}
+
+	// addFile writes the file node into VFS source, file bytes go into data
+	// blob in 'goembed' embed mode otherwise hex escaped string literal.
+	// Index entry is updated with stored bytes details, if given and file is
+	// written successfully.
+	addFile := func(node *vfs.NodeInfo, e *vfsIndexEntry, write func(w io.Writer) error) (err error) {
+		var sw *vfsStoredWriter
+		defer func() {
+			if err == nil && sw != nil && e != nil {
+				sw.Update(e)
+				idx.Files = append(idx.Files, e)
+			}
+		}()
+
+		if blob != nil {
+			offset := blob.Len()
+			sw = newVFSStoredWriter(blob)
+			if err = write(sw); err != nil {
+				return err
+			}
+			return vfsTmpl.ExecuteTemplate(buf, "vfs_file_blob", aah.Data{
+				"Node":    node,
+				"BlobVar": blobVar,
+				"Offset":  offset,
+				"End":     blob.Len(),
+			})
+		}
+
+		if err = vfsTmpl.ExecuteTemplate(buf, "vfs_file", aah.Data{
+			"Node": node,
+		}); err != nil {
+			return err
+		}
+		sw = newVFSStoredWriter(&stringWriter{w: buf})
+		if err = write(sw); err != nil {
+			return err
+		}
+		_s(fmt.Fprint(buf, "\"))\n\n"))
+		return nil
 	}
 
 	_s(fmt.Fprintf(buf, "\n// Adding files into VFS\n"))
-	for fname, info := range files {
-		f, err := os.Open(fname)
+	for _, fname := range fnames {
+		info := files[fname]
+		srcFile := fname
+		if sf, found := srcFiles[fname]; found {
+			srcFile = sf
+		}
+		f, err := os.Open(srcFile)
 		if err != nil {
 			logError(err)
 			continue
@@ -127,39 +286,127 @@ func generateVFSSource(mode bool, appBaseDir, vroot, proot string, skipList ess.
 
 		cliLog.Debugf("     |-- Processing: %s", fname)
 		mp := filepath.ToSlash(filepath.Join(vroot, strings.TrimPrefix(fname, proot)))
-
-		if err = vfsTmpl.ExecuteTemplate(buf, "vfs_file", aah.Data{
-			"Node": &vfs.NodeInfo{DataSize: info.Size(), Path: mp, Time: info.ModTime()},
+		node := &vfs.NodeInfo{DataSize: info.Size(), Path: mp, Time: modTime(info.ModTime())}
+		sum, err := contentSum(f)
+		if err != nil {
+			ess.CloseQuietly(f)
+			logError(err)
+			return nil, nil, err
+		}
+		entry := &vfsIndexEntry{Path: mp, Time: node.Time, Size: info.Size(), SHA256: sum}
+		if srcFile != fname {
+			entry.Origin = vfsOriginAsset
+		}
+		if err = addFile(node, entry, func(w io.Writer) error {
+			if info.Size() == 0 {
+				return nil
+			}
+			return convertFile(w, f, info, noGzip(noGzipList, info.Name()))
 		}); err != nil {
+			ess.CloseQuietly(f)
 			logError(err)
-			return nil, err
+			return nil, nil, err
 		}
 
-		if info.Size() > 0 {
-			if err = convertFile(buf, f, info, noGzip(noGzipList, info.Name())); err != nil {
+		// precompressed variants go next to the file e.g. 'app.css.br'
+		if vfsPrecompressPolicy != nil && !noGzip(noGzipList, info.Name()) {
+			variants, err := vfsPrecompressPolicy.Variants(f, info)
+			if err != nil {
+				ess.CloseQuietly(f)
 				logError(err)
-				return nil, err
+				return nil, nil, err
+			}
+			for _, v := range variants {
+				if _, exists := files[fname+v.Ext]; exists {
+					cliLog.Debugf("     |-- Skipping %s variant, file exists: %s", v.Encoding, fname+v.Ext)
+					continue
+				}
+				data := v.Data
+				if err = addFile(&vfs.NodeInfo{
+					DataSize: int64(len(data)),
+					Path:     mp + v.Ext,
+					Time:     node.Time,
+				}, &vfsIndexEntry{
+					Path:     mp + v.Ext,
+					Time:     node.Time,
+					Size:     int64(len(data)),
+					Encoding: v.Encoding,
+					Origin:   vfsOriginVariant,
+					SHA256:   sum,
+				}, func(w io.Writer) error {
+					_, err := w.Write(data)
+					return err
+				}); err != nil {
+					ess.CloseQuietly(f)
+					logError(err)
+					return nil, nil, err
+				}
+				mountVariants[mp] = append(mountVariants[mp], v.Encoding)
 			}
 		}
-		_s(fmt.Fprint(buf, "\"))\n\n"))
 		ess.CloseQuietly(f)
 	}
 
-	_s(fmt.Fprint(buf, "}"))
-	return format.Source(buf.Bytes())
-}
+	// mount index goes as Go string variable outside of VFS, so it's not
+	// served by the application. It's located within binary by its marker.
+	if vfsIndexEnabled {
+		idxBytes, err := json.Marshal(idx)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err = vfsTmpl.ExecuteTemplate(buf, "vfs_index", aah.Data{
+			"IndexVar": "vfsIndex" + varSuffix,
+			"Index":    strconv.Quote(string(idxBytes)),
+		}); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		_s(fmt.Fprint(buf, "}"))
+	}
+	b, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, nil, err
+	}
 
-func convertFile(buf *bytes.Buffer, r io.ReadSeeker, fi os.FileInfo, noGzip bool) error {
-	restorePoint := buf.Len()
-	w := &stringWriter{w: buf}
+	var blobBytes []byte
+	if blob != nil {
+		blobBytes = blob.Bytes()
+	}
+	addVFSVariants(mountVariants)
+	if vfsCacheStore != nil {
+		vfsCacheStore.SaveMountSource(vroot, fingerprint, b, blobBytes, mountVariants)
+	}
+	return b, blobBytes, nil
+}
 
+// convertFile method writes the file content into writer, it's gzipped if
+// size is above MTU size and gzip is beneficial.
+func convertFile(w io.Writer, r io.ReadSeeker, fi os.FileInfo, noGzip bool) error {
 	// if its already less then MTU size or gzip not required
 	if fi.Size() <= defaultGzipMinSize || noGzip {
 		_, err := io.Copy(w, r)
 		return err
 	}
 
-	gw := gzip.NewWriter(w)
+	// previously gzipped content from cache
+	var sum string
+	if vfsCacheStore != nil {
+		var err error
+		if sum, err = contentSum(r); err != nil {
+			return err
+		}
+		if b, found := vfsCacheStore.GzipBytes(sum); found {
+			if b == nil {
+				_, err = io.Copy(w, r)
+				return err
+			}
+			_, err = w.Write(b)
+			return err
+		}
+	}
+
+	gzBuf := &bytes.Buffer{}
+	gw := gzip.NewWriter(gzBuf)
 	_, err := io.Copy(gw, r)
 	if err != nil {
 		return err
@@ -169,18 +416,23 @@ func convertFile(buf *bytes.Buffer, r io.ReadSeeker, fi os.FileInfo, noGzip bool
 		return err
 	}
 
-	if int64(w.size) >= fi.Size() {
-		if _, err = r.Seek(0, io.SeekStart); err != nil {
-			return err
+	// gzip has no benefit, go with content as-is
+	if int64(gzBuf.Len()) >= fi.Size() {
+		if vfsCacheStore != nil {
+			vfsCacheStore.SaveGzipBytes(sum, nil)
 		}
-
-		buf.Truncate(restorePoint)
-		if _, err = io.Copy(w, r); err != nil {
+		if _, err = r.Seek(0, io.SeekStart); err != nil {
 			return err
 		}
+		_, err = io.Copy(w, r)
+		return err
 	}
 
-	return nil
+	if vfsCacheStore != nil {
+		vfsCacheStore.SaveGzipBytes(sum, gzBuf.Bytes())
+	}
+	_, err = w.Write(gzBuf.Bytes())
+	return err
 }
 
 const lowerHex = "0123456789abcdef"
@@ -232,13 +484,18 @@ const vfsTmplStr = `{{ define "vfs_start_mount"}} // Code generated by aah CLI -
 
 package generated
 
-import ({{ if .Mode }}
+import ({{ if .BlobFile }}
+	_ "embed"{{ end }}{{ if .Index }}
+	"runtime"{{ end }}{{ if .Mode }}
 	"time"{{ end }}
 	
 	"aahframe.work"{{ if .Mode }}
 	"aahframe.work/vfs"{{ end }}
 )
-
+{{ if .BlobFile }}
+//go:embed {{ .BlobFile }}
+var {{ .BlobVar }} []byte
+{{ end }}
 func init() {
 	app := aah.App()
 	{{ if .Mode }}app.VFS().SetEmbeddedMode(){{ end }}
@@ -275,4 +532,21 @@ func init() {
 	},
 	[]byte("
 {{- end }}
+
+{{ define "vfs_file_blob" }}
+	m.AddFile(&vfs.NodeInfo{
+		DataSize: {{ .Node.DataSize }},
+		Path: "{{ .Node.Path }}",
+		Time: {{ .Node.Time | timestr }},
+	},
+	{{ .BlobVar }}[{{ .Offset }}:{{ .End }}:{{ .End }}])
+{{ end }}
+
+{{ define "vfs_index" }}
+	// mount index is not added into VFS, it's kept within binary for 'aah vfs' command
+	runtime.KeepAlive({{ .IndexVar }})
+}
+
+var {{ .IndexVar }} = {{ .Index }}
+{{ end }}
 `
@@ -9,11 +9,13 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"aahframe.work"
+	"aahframe.work/config"
 	"aahframe.work/console"
 	"aahframe.work/essentials"
 	"aahframe.work/log"
@@ -30,7 +32,12 @@ var generateCmd = console.Command{
 		aah help generate
 
 	To know more about individual sub-commands details:
-		aah generate help script`,
+		aah generate help script
+		aah generate help controller
+		aah generate help resource
+		aah generate help middleware
+		aah generate help event-handler
+		aah generate help auth-provider`,
 	Subcommands: []console.Command{
 		{
 			Name:    "script",
@@ -38,17 +45,222 @@ var generateCmd = console.Command{
 			Usage:   "Generates complement scripts such as systemd, dockerize, etc.",
 			Description: `Generates complement scripts such as systemd, dockerize, etc.
 
+	Override templates are looked up first in '<app-base-dir>/.aah/templates'
+	then '<aahpath>/templates' as '<template-name>.tmpl' (e.g. 'Dockerfile.prod.tmpl')
+	before falling back to the built-in templates. Use '--eject' to write out
+	the built-in templates of the script for customization.
+
+	Systemd socket activation is not supported, aah application opens its own
+	listener and does not accept inherited file descriptor (LISTEN_FDS). Key
+	'generate.systemd.socket_activation' in 'aah.project' is ignored.
+
 	Examples:
 		aah generate script --name systemd
-		aah generate script --name docker`,
+		aah generate script --name systemd --install-dir /opt/myapp --user aah --group aah --logrotate
+		aah generate script --name docker
+		aah generate script --name k8s
+		aah generate script --name k8s --kustomize --image registry.example.com/myapp:1.0.0
+		aah generate script --name compose --sidecars redis
+		aah generate script --name devcontainer
+		aah generate script --name docker --eject`,
 			Flags: []console.Flag{
 				console.StringFlag{
 					Name:  "name, n",
-					Usage: "Provide script target such as 'systemd', 'docker', etc",
+					Usage: "Provide script target such as 'systemd', 'docker', 'k8s', 'compose', 'devcontainer', etc",
+				},
+				console.StringFlag{
+					Name:  "envprofile, e",
+					Value: "prod",
+					Usage: "Environment profile name used by the scripts (systemd, k8s, compose uses 'dev')",
+				},
+				console.StringFlag{
+					Name:  "image",
+					Usage: "Container image name, default is '<app-name>:latest' (k8s)",
+				},
+				console.IntFlag{
+					Name:  "replicas",
+					Value: 2,
+					Usage: "Number of replicas (k8s)",
+				},
+				console.StringFlag{
+					Name:  "namespace",
+					Usage: "Kubernetes namespace (k8s)",
+				},
+				console.StringFlag{
+					Name:  "host",
+					Usage: "Ingress host name, default is '<app-name>.example.com' (k8s)",
+				},
+				console.BoolFlag{
+					Name:  "kustomize",
+					Usage: "Generates Kustomize base and overlay per environment profile (k8s)",
+				},
+				console.StringFlag{
+					Name:  "install-dir",
+					Usage: "Application install directory, default is '/home/aah/<app-name>' (systemd)",
+				},
+				console.StringFlag{
+					Name:  "user",
+					Usage: "User to run the application as (systemd)",
+				},
+				console.StringFlag{
+					Name:  "group",
+					Usage: "Group to run the application as (systemd)",
+				},
+				console.StringFlag{
+					Name:  "env-file",
+					Usage: "Environment file path, default is '<install-dir>_env_values' (systemd)",
+				},
+				console.StringFlag{
+					Name:  "restart",
+					Usage: "Restart policy such as 'on-failure', 'always', etc., default is 'on-failure' (systemd)",
+				},
+				console.IntFlag{
+					Name:  "limit-nofile",
+					Usage: "Maximum number of open files (LimitNOFILE) (systemd)",
+				},
+				console.StringFlag{
+					Name:  "protect-system",
+					Usage: "Value for ProtectSystem such as 'true', 'full', 'strict' (systemd)",
+				},
+				console.BoolFlag{
+					Name:  "no-new-privileges",
+					Usage: "Sets NoNewPrivileges=true (systemd)",
+				},
+				console.BoolFlag{
+					Name:  "logrotate",
+					Usage: "Generates logrotate config for the application file log receiver (systemd)",
+				},
+				console.BoolFlag{
+					Name:  "eject",
+					Usage: "Writes out the built-in templates of the script into '<app-base-dir>/.aah/templates'",
+				},
+				console.StringFlag{
+					Name:  "sidecars",
+					Usage: "Comma separated sidecars 'redis', 'memcache', default is inferred from the cache providers in use (compose)",
 				},
 			},
 			Action: generateScriptsAction,
 		},
+		{
+			Name:      "controller",
+			Aliases:   []string{"c"},
+			Usage:     "Generates controller or websocket with actions and its routes",
+			ArgsUsage: "<Name>",
+			Description: `Generates controller (or websocket) type with given actions, appends the routes
+	into 'config/routes.conf' and validates the result same as compile does.
+
+	Actions Index, Show, Create, Update, Delete, New and Edit are mapped to REST style
+	routes, others are mapped to 'GET /<name>/<action>'.
+
+	Examples:
+		aah generate controller User --actions Index,Show,Create
+		aah generate controller User --actions Index,Show,Create --api v1
+		aah generate controller Chat --websocket`,
+			Flags: []console.Flag{
+				console.StringFlag{
+					Name:  "actions, a",
+					Usage: "Comma separated action names, default is 'Index' and 'Handle' for websocket",
+				},
+				console.StringFlag{
+					Name:  "api",
+					Usage: "API version, controller is created under 'app/controllers/<version>' with JSON replies",
+				},
+				console.BoolFlag{
+					Name:  "websocket",
+					Usage: "Generates websocket under 'app/websockets' instead of controller",
+				},
+				console.StringFlag{
+					Name:  "domain",
+					Usage: "Domain key in 'routes.conf' to add routes, default is first domain",
+				},
+			},
+			Action: generateControllerAction,
+		},
+		{
+			Name:      "resource",
+			Aliases:   []string{"r"},
+			Usage:     "Generates REST resource model, controller, routes and views",
+			ArgsUsage: "<Name> [field:type ...]",
+			Description: `Generates REST resource for the given name and fields, it creates model in
+	'app/models', controller with actions List, Get, Create, Update, Delete and its routes
+	in 'config/routes.conf'. For 'web' application view files are created for the
+	view engine configured in 'aah.conf'.
+
+	Supported field types are string, text, int, int64, uint, float, float64, bool, time.
+	Field type is optional, default is 'string'.
+
+	Examples:
+		aah generate resource Book title:string price:float
+		aah generate resource Book title price:float --api v1`,
+			Flags: []console.Flag{
+				console.StringFlag{
+					Name:  "api",
+					Usage: "API version, controller is created under 'app/controllers/<version>' with JSON replies",
+				},
+				console.StringFlag{
+					Name:  "domain",
+					Usage: "Domain key in 'routes.conf' to add routes, default is first domain",
+				},
+			},
+			Action: generateResourceAction,
+		},
+		{
+			Name:      "middleware",
+			Aliases:   []string{"m"},
+			Usage:     "Generates middleware and registers it in 'app/init.go'",
+			ArgsUsage: "<Name>",
+			Description: `Generates middleware in 'app/middleware' and registers it in 'app/init.go'
+	before 'aah.ActionMiddleware'.
+
+	Examples:
+		aah generate middleware RequestTimer`,
+			Action: generateMiddlewareAction,
+		},
+		{
+			Name:      "event-handler",
+			Aliases:   []string{"e"},
+			Usage:     "Generates event handler and registers it in 'app/init.go'",
+			ArgsUsage: "<Name>",
+			Description: `Generates event handler func in 'app/init.go' and registers it for the event.
+
+	Supported events are OnInit, OnStart, OnPreShutdown, OnPostShutdown, OnRequest,
+	OnPreReply, OnPostReply, OnPreAuth, OnPostAuth.
+
+	Examples:
+		aah generate event-handler ConnectCache --event OnStart
+		aah generate event-handler LogRequest --event OnRequest`,
+			Flags: []console.Flag{
+				console.StringFlag{
+					Name:  "event",
+					Usage: "Event name to register the handler",
+				},
+			},
+			Action: generateEventHandlerAction,
+		},
+		{
+			Name:      "auth-provider",
+			Aliases:   []string{"a"},
+			Usage:     "Generates authenticator, principal provider or authorizer for auth scheme",
+			ArgsUsage: "<Name>",
+			Description: `Generates auth provider in 'app/security' and configures it for the auth scheme
+	in 'config/security.conf', i.e. 'security.auth_schemes.<scheme>.<type>'.
+
+	Examples:
+		aah generate auth-provider User --type authenticator --scheme form_auth
+		aah generate auth-provider User --type principal --scheme form_auth
+		aah generate auth-provider User --type authorizer --scheme form_auth`,
+			Flags: []console.Flag{
+				console.StringFlag{
+					Name:  "type",
+					Usage: "Auth provider type 'authenticator', 'principal' or 'authorizer'",
+				},
+				console.StringFlag{
+					Name:  "scheme",
+					Usage: "Auth scheme name from 'security.auth_schemes'",
+				},
+			},
+			Action: generateAuthProviderAction,
+		},
 	},
 }
 
@@ -67,14 +279,27 @@ func generateScriptsAction(c *console.Context) error {
 		return nil
 	}
 
+	if c.Bool("eject") {
+		if err := ejectScriptTemplates(c, scriptName); err != nil {
+			logFatal(err)
+		}
+		return nil
+	}
+
 	var err error
 	switch scriptName {
 	case "systemd":
 		err = generateSystemdScript(c)
 	case "docker":
 		err = generateDockerScript(c)
+	case "k8s":
+		err = generateK8sScript(c)
+	case "compose":
+		err = generateComposeScript(c)
+	case "devcontainer":
+		err = generateDevContainerScript(c)
 	default:
-		log.Error("Unsupported 'script' name, try one of these 'systemd', 'docker'")
+		log.Error("Unsupported 'script' name, try one of these 'systemd', 'docker', 'k8s', 'compose', 'devcontainer'")
 	}
 
 	if err != nil {
@@ -89,20 +314,7 @@ func generateScriptsAction(c *console.Context) error {
 //___________________________________
 
 func generateSystemdScript(c *console.Context) error {
-	importPath := appImportPath(c)
-	if ess.IsStrEmpty(importPath) {
-		logFatalf("Unable to infer import path, ensure you're in the aah application base directory")
-	}
-	chdirIfRequired(importPath)
-	app := aah.App()
-	if err := app.InitForCLI(importPath); err != nil {
-		logFatal(err)
-	}
-
-	projectCfg := aahProjectCfg(app.BaseDir())
-	cliLog = initCLILogger(projectCfg)
-
-	cliLog.Infof("Loaded aah project file: %s\n", filepath.Join(app.BaseDir(), aahProjectIdentifier))
+	app, projectCfg := initAppForScript(c)
 
 	appName := strings.ToLower(projectCfg.StringDefault("name", app.Name()))
 	fileName := fmt.Sprintf("%s.service", appName)
@@ -111,27 +323,132 @@ func generateSystemdScript(c *console.Context) error {
 		return nil
 	}
 
+	opts := systemdOptions(c, projectCfg, appName)
 	data := map[string]interface{}{
 		"AppName":    appName,
 		"FileName":   fileName,
 		"CreateDate": time.Now().Format(time.RFC1123Z),
 		"Desc":       fmt.Sprintf("%s application", appName),
+		"Opts":       opts,
+		"Port":       appPortForScript(app.Config()),
+	}
+
+	tmpl, err := scriptTemplate("systemd.service")
+	if err != nil {
+		return err
 	}
 
 	var buf bytes.Buffer
-	if err := renderTmpl(&buf, aahSystemdScriptTemplate, data); err != nil {
+	if err := renderTmpl(&buf, tmpl, data); err != nil {
 		return fmt.Errorf("Unable to create systemd service file: %s", err)
 	}
 	if err := ioutil.WriteFile(destFile, buf.Bytes(), permRWXRXRX); err != nil {
 		return fmt.Errorf("Unable to create systemd service file: %s", err)
 	}
-
 	cliLog.Infof("Generated 'systemd' service file at '%s'\n", destFile)
+
+	if opts.Logrotate {
+		if err := generateLogrotateScript(c, app.Config(), appName, opts); err != nil {
+			return err
+		}
+	}
+
 	cliLog.Infof("What's next, refer to https://docs.aahframework.org/getting-started-with-systemd.html#steps-to-configure-and-enable\n")
 
 	return nil
 }
 
+// systemdOpts holds the values used to render systemd unit and logrotate config.
+type systemdOpts struct {
+	InstallDir      string
+	User            string
+	Group           string
+	EnvProfile      string
+	EnvFile         string
+	Restart         string
+	LimitNOFILE     int
+	ProtectSystem   string
+	NoNewPrivileges bool
+	Logrotate       bool
+}
+
+// systemdOptions method resolves the systemd options, command flag takes
+// precedence over 'generate.systemd' block in the 'aah.project' file then
+// the defaults.
+func systemdOptions(c *console.Context, projectCfg *config.Config, appName string) *systemdOpts {
+	const keyPrefix = "generate.systemd."
+	strOpt := func(flag, key, defaultValue string) string {
+		if c.IsSet(flag) {
+			if v := strings.TrimSpace(c.String(flag)); !ess.IsStrEmpty(v) {
+				return v
+			}
+		}
+		return strings.TrimSpace(projectCfg.StringDefault(keyPrefix+key, defaultValue))
+	}
+	boolOpt := func(flag, key string) bool {
+		if c.IsSet(flag) {
+			return c.Bool(flag)
+		}
+		return projectCfg.BoolDefault(keyPrefix+key, false)
+	}
+
+	opts := &systemdOpts{
+		InstallDir:      strings.TrimRight(strOpt("install-dir", "install_dir", "/home/aah/"+appName), "/"),
+		User:            strOpt("user", "user", ""),
+		Group:           strOpt("group", "group", ""),
+		EnvProfile:      strOpt("envprofile", "env_profile", "prod"),
+		Restart:         strOpt("restart", "restart", "on-failure"),
+		ProtectSystem:   strOpt("protect-system", "protect_system", ""),
+		NoNewPrivileges: boolOpt("no-new-privileges", "no_new_privileges"),
+		Logrotate:       boolOpt("logrotate", "logrotate"),
+	}
+	if projectCfg.IsExists(keyPrefix + "socket_activation") {
+		cliLog.Warnf("'%ssocket_activation' is ignored, aah application does not accept inherited listener from systemd socket", keyPrefix)
+	}
+	opts.EnvFile = strOpt("env-file", "env_file", opts.InstallDir+"_env_values")
+	if c.IsSet("limit-nofile") {
+		opts.LimitNOFILE = c.Int("limit-nofile")
+	} else {
+		opts.LimitNOFILE = projectCfg.IntDefault(keyPrefix+"limit_nofile", 0)
+	}
+	return opts
+}
+
+func generateLogrotateScript(c *console.Context, appCfg *config.Config, appName string, opts *systemdOpts) error {
+	if receiver := appCfg.StringDefault("log.receiver", ""); receiver != "file" {
+		cliLog.Warnf("Application log receiver is '%s', logrotate config is applicable only for 'file' receiver\n", receiver)
+		return nil
+	}
+
+	logFile := appCfg.StringDefault("log.file", "")
+	if ess.IsStrEmpty(logFile) {
+		logFile = filepath.Join("logs", appName+".log")
+	}
+	if !filepath.IsAbs(logFile) {
+		logFile = path.Join(opts.InstallDir, filepath.ToSlash(logFile))
+	}
+
+	fileName := fmt.Sprintf("%s.logrotate", appName)
+	destFile := filepath.Join(aah.App().BaseDir(), fileName)
+	if checkAndConfirmOverwrite(c, destFile) {
+		return nil
+	}
+
+	data := map[string]interface{}{
+		"AppName":    appName,
+		"FileName":   fileName,
+		"CreateDate": time.Now().Format(time.RFC1123Z),
+		"LogPattern": path.Join(path.Dir(logFile), "*"+path.Ext(logFile)),
+		"Opts":       opts,
+	}
+	if err := writeScriptFile(destFile, "logrotate", data); err != nil {
+		return err
+	}
+
+	cliLog.Infof("Generated 'logrotate' config at '%s', install it as '/etc/logrotate.d/%s'\n", destFile, appName)
+	return nil
+}
+
 func generateDockerScript(c *console.Context) error {
 	importPath := appImportPath(c)
 	if ess.IsStrEmpty(importPath) {
@@ -181,8 +498,17 @@ func generateDockerScript(c *console.Context) error {
 		"CodeVersion":   codeVersion,
 	}
 
+	devTmpl, err := scriptTemplate("Dockerfile.dev")
+	if err != nil {
+		return err
+	}
+	prodTmpl, err := scriptTemplate("Dockerfile.prod")
+	if err != nil {
+		return err
+	}
+
 	buf := &bytes.Buffer{}
-	if err := renderTmpl(buf, aahDockerDevScriptTemplate, devData); err != nil {
+	if err := renderTmpl(buf, devTmpl, devData); err != nil {
 		return fmt.Errorf("Unable to create %s: %s", devFileName, err)
 	}
 	if err := ioutil.WriteFile(devDestFile, buf.Bytes(), permRWRWRW); err != nil {
@@ -191,7 +517,7 @@ func generateDockerScript(c *console.Context) error {
 	_ = ess.ApplyFileMode(devDestFile, permRWRWRW)
 
 	buf.Reset()
-	if err := renderTmpl(buf, aahDockerProdScriptTemplate, prodData); err != nil {
+	if err := renderTmpl(buf, prodTmpl, prodData); err != nil {
 		return fmt.Errorf("Unable to create %s: %s", prodFileName, err)
 	}
 	if err := ioutil.WriteFile(prodDestFile, buf.Bytes(), permRWRWRW); err != nil {
@@ -205,6 +531,321 @@ func generateDockerScript(c *console.Context) error {
 	return nil
 }
 
+func generateK8sScript(c *console.Context) error {
+	app, projectCfg := initAppForScript(c)
+	appCfg := app.Config()
+	appName := strings.ToLower(projectCfg.StringDefault("name", app.Name()))
+	envProfile := strings.TrimSpace(c.String("envprofile"))
+	if ess.IsStrEmpty(envProfile) {
+		envProfile = "prod"
+	}
+
+	// Health check path, 'server.health' or 'server.health.path'
+	healthPath, found := appCfg.String("server.health")
+	if !found {
+		healthPath = appCfg.StringDefault("server.health.path", "")
+	}
+
+	isSSL := appCfg.BoolDefault("server.ssl.enable", false)
+	data := map[string]interface{}{
+		"AppName":    appName,
+		"BinaryName": projectCfg.StringDefault("build.binary_name", appName),
+		"CreateDate": time.Now().Format(time.RFC1123Z),
+		"EnvProfile": envProfile,
+		"Image":      firstNonEmpty(c.String("image"), appName+":latest"),
+		"Replicas":   c.Int("replicas"),
+		"Namespace":  c.String("namespace"),
+		"Host":       firstNonEmpty(c.String("host"), appName+".example.com"),
+		"Port":       appPortForScript(appCfg),
+		"SSL":        isSSL,
+		"HealthPath": healthPath,
+	}
+
+	k8sDir := filepath.Join(app.BaseDir(), "k8s")
+	manifestDir := k8sDir
+	if c.Bool("kustomize") {
+		manifestDir = filepath.Join(k8sDir, "base")
+	}
+
+	files := []struct{ name, tmpl string }{
+		{"deployment.yaml", "k8s-deployment.yaml"},
+		{"service.yaml", "k8s-service.yaml"},
+		{"ingress.yaml", "k8s-ingress.yaml"},
+	}
+	if !c.Bool("kustomize") {
+		files = append(files, struct{ name, tmpl string }{"configmap.yaml", "k8s-configmap.yaml"})
+	}
+
+	// env profile config carried via ConfigMap
+	envConfig, err := ioutil.ReadFile(filepath.Join(app.BaseDir(), "config", "env", envProfile+".conf"))
+	if err != nil {
+		cliLog.Warnf("Environment profile config not found: %s", err)
+	}
+	data["EnvConfig"] = indentLines(string(envConfig), "    ")
+
+	var generated []string
+	for _, f := range files {
+		data["FileName"] = f.name
+		destFile := filepath.Join(manifestDir, f.name)
+		if checkAndConfirmOverwrite(c, destFile) {
+			return nil
+		}
+		if err = writeScriptFile(destFile, f.tmpl, data); err != nil {
+			return err
+		}
+		generated = append(generated, destFile)
+	}
+
+	if c.Bool("kustomize") {
+		files, err := generateKustomize(c, app.BaseDir(), k8sDir, envProfile, data)
+		if err != nil {
+			return err
+		}
+		generated = append(generated, files...)
+	}
+
+	cliLog.Infof("Generated Kubernetes manifests at \n\t%s\n", strings.Join(generated, "\n\t"))
+	cliLog.Infof("What's next, build and push the image using 'Dockerfile.prod' then apply the manifests\n")
+
+	return nil
+}
+
+// composeSidecars maps the cache provider name to its sidecar service.
+var composeSidecars = map[string]struct {
+	Image string
+	Port  string
+}{
+	"redis":    {Image: "redis:alpine", Port: "6379"},
+	"memcache": {Image: "memcached:alpine", Port: "11211"},
+}
+
+func generateComposeScript(c *console.Context) error {
+	app, projectCfg := initAppForScript(c)
+	appName := strings.ToLower(projectCfg.StringDefault("name", app.Name()))
+
+	fileName := "docker-compose.yml"
+	destFile := filepath.Join(app.BaseDir(), fileName)
+	if checkAndConfirmOverwrite(c, destFile) {
+		return nil
+	}
+
+	var sidecars []string
+	if names := strings.TrimSpace(c.String("sidecars")); !ess.IsStrEmpty(names) {
+		for _, name := range strings.Split(names, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if _, found := composeSidecars[name]; !found {
+				return fmt.Errorf("Unsupported sidecar '%s', supported sidecars are 'redis', 'memcache'", name)
+			}
+			sidecars = append(sidecars, name)
+		}
+	} else {
+		sidecars = inferCacheProviders(app.BaseDir(), app.Config())
+	}
+
+	type sidecar struct{ Name, Image, Port string }
+	var services []sidecar
+	for _, name := range sidecars {
+		services = append(services, sidecar{Name: name, Image: composeSidecars[name].Image, Port: composeSidecars[name].Port})
+	}
+
+	data := map[string]interface{}{
+		"AppName":    appName,
+		"FileName":   fileName,
+		"CreateDate": time.Now().Format(time.RFC1123Z),
+		"Port":       appPortForScript(app.Config()),
+		"Sidecars":   services,
+	}
+	if err := writeScriptFile(destFile, "docker-compose.yml", data); err != nil {
+		return err
+	}
+
+	cliLog.Infof("Generated '%s' at '%s'\n", fileName, destFile)
+	if !ess.IsFileExists(filepath.Join(app.BaseDir(), "Dockerfile.dev")) {
+		cliLog.Infof("What's next, generate 'Dockerfile.dev' via 'aah generate script --name docker'\n")
+	}
+	return nil
+}
+
+func generateDevContainerScript(c *console.Context) error {
+	app, projectCfg := initAppForScript(c)
+	appName := strings.ToLower(projectCfg.StringDefault("name", app.Name()))
+
+	fileName := "devcontainer.json"
+	destFile := filepath.Join(app.BaseDir(), ".devcontainer", fileName)
+	if checkAndConfirmOverwrite(c, destFile) {
+		return nil
+	}
+
+	data := map[string]interface{}{
+		"AppName":    appName,
+		"Port":       appPortForScript(app.Config()),
+		"UseCompose": ess.IsFileExists(filepath.Join(app.BaseDir(), "docker-compose.yml")),
+	}
+	if err := writeScriptFile(destFile, "devcontainer.json", data); err != nil {
+		return err
+	}
+
+	cliLog.Infof("Generated '%s' at '%s'\n", fileName, destFile)
+	return nil
+}
+
+func initAppForScript(c *console.Context) (*aah.Application, *config.Config) {
+	importPath := appImportPath(c)
+	if ess.IsStrEmpty(importPath) {
+		logFatalf("Unable to infer import path, ensure you're in the aah application base directory")
+	}
+	chdirIfRequired(importPath)
+	app := aah.App()
+	if err := app.InitForCLI(importPath); err != nil {
+		logFatal(err)
+	}
+	projectCfg := aahProjectCfg(app.BaseDir())
+	cliLog = initCLILogger(projectCfg)
+
+	cliLog.Infof("Loaded aah project file: %s\n", filepath.Join(app.BaseDir(), aahProjectIdentifier))
+	return app, projectCfg
+}
+
+// appPortForScript method returns the application HTTP port from 'aah.conf',
+// default is '8080'. Empty port means the standard port of HTTP or HTTPS.
+func appPortForScript(appCfg *config.Config) string {
+	port := appCfg.StringDefault("server.port", "8080")
+	if ess.IsStrEmpty(port) {
+		if appCfg.BoolDefault("server.ssl.enable", false) {
+			return "443"
+		}
+		return "80"
+	}
+	return port
+}
+
+// inferCacheProviders method returns the cache providers used by application,
+// it is inferred from cache modules in 'go.mod' (official modules listed in
+// migrate grammar) and cache providers configured in 'aah.conf'.
+func inferCacheProviders(baseDir string, appCfg *config.Config) []string {
+	cacheModules := []string{"aahframe.work/cache/provider/redis", "aahframe.work/cache/provider/memcache"}
+	if grammarCfg, err := config.LoadFile(filepath.Join(aahPath(), aahGrammarIdentifier)); err == nil {
+		if modules, found := grammarCfg.StringList("file.go.official_modules"); found {
+			cacheModules = cacheModules[:0]
+			for _, m := range modules {
+				if strings.Contains(m, "/cache/") {
+					cacheModules = append(cacheModules, m)
+				}
+			}
+		}
+	}
+
+	found := make(map[string]bool)
+	if goMod, err := ioutil.ReadFile(filepath.Join(baseDir, goModIdentifier)); err == nil {
+		for _, m := range cacheModules {
+			if bytes.Contains(goMod, []byte(m+" ")) {
+				found[path.Base(m)] = true
+			}
+		}
+	}
+	for _, key := range appCfg.KeysByPath("cache") {
+		found[appCfg.StringDefault("cache."+key+".provider", "")] = true
+	}
+
+	var providers []string
+	for _, name := range []string{"redis", "memcache"} {
+		if found[name] {
+			providers = append(providers, name)
+		}
+	}
+	return providers
+}
+
+// generateKustomize method creates Kustomize base and overlay for each
+// environment profile found in 'config/env'.
+func generateKustomize(c *console.Context, baseDir, k8sDir, envProfile string, data map[string]interface{}) ([]string, error) {
+	var generated []string
+
+	// base, env profile config is copied as 'app.conf'
+	baseConf := filepath.Join(k8sDir, "base", "app.conf")
+	if _, err := ess.CopyFile(baseConf, filepath.Join(baseDir, "config", "env", envProfile+".conf")); err != nil {
+		cliLog.Warnf("Unable to copy environment profile config: %s", err)
+		_ = ioutil.WriteFile(baseConf, []byte{}, permRWRWRW)
+	}
+	generated = append(generated, baseConf)
+
+	baseFile := filepath.Join(k8sDir, "base", "kustomization.yaml")
+	data["FileName"] = "kustomization.yaml"
+	if checkAndConfirmOverwrite(c, baseFile) {
+		return generated, nil
+	}
+	if err := writeScriptFile(baseFile, "kustomize-base.yaml", data); err != nil {
+		return nil, err
+	}
+	generated = append(generated, baseFile)
+
+	envFiles, _ := filepath.Glob(filepath.Join(baseDir, "config", "env", "*.conf"))
+	for _, envFile := range envFiles {
+		profile := ess.StripExt(filepath.Base(envFile))
+		overlayDir := filepath.Join(k8sDir, "overlays", profile)
+		if err := ess.MkDirAll(overlayDir, permRWXRXRX); err != nil {
+			return nil, err
+		}
+
+		overlayConf := filepath.Join(overlayDir, "app.conf")
+		if _, err := ess.CopyFile(overlayConf, envFile); err != nil {
+			return nil, err
+		}
+
+		overlayFile := filepath.Join(overlayDir, "kustomization.yaml")
+		if checkAndConfirmOverwrite(c, overlayFile) {
+			continue
+		}
+		data["OverlayProfile"] = profile
+		if err := writeScriptFile(overlayFile, "kustomize-overlay.yaml", data); err != nil {
+			return nil, err
+		}
+		generated = append(generated, overlayConf, overlayFile)
+	}
+	return generated, nil
+}
+
+// writeScriptFile method renders the script template of given name into
+// destination file.
+func writeScriptFile(destFile, tmplName string, data map[string]interface{}) error {
+	fileName := filepath.Base(destFile)
+	tmpl, err := scriptTemplate(tmplName)
+	if err != nil {
+		return err
+	}
+	if err := ess.MkDirAll(filepath.Dir(destFile), permRWXRXRX); err != nil {
+		return fmt.Errorf("Unable to create %s: %s", fileName, err)
+	}
+	buf := &bytes.Buffer{}
+	if err := renderTmpl(buf, tmpl, data); err != nil {
+		return fmt.Errorf("Unable to create %s: %s", fileName, err)
+	}
+	if err := ioutil.WriteFile(destFile, buf.Bytes(), permRWRWRW); err != nil {
+		return fmt.Errorf("Unable to create %s: %s", fileName, err)
+	}
+	_ = ess.ApplyFileMode(destFile, permRWRWRW)
+	return nil
+}
+
+func indentLines(s, indent string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, l := range lines {
+		if !ess.IsStrEmpty(l) {
+			lines[i] = indent + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); !ess.IsStrEmpty(v) {
+			return v
+		}
+	}
+	return ""
+}
+
 func checkAndConfirmOverwrite(c *console.Context, destFile string) bool {
 	if ess.IsFileExists(destFile) {
 		cliLog.Warnf("File: %s already exists, it will be overwritten.", destFile)
@@ -249,17 +890,39 @@ Description={{ .Desc }}
 After=network.target
 
 [Service]
-#User=aah
-#Group=aah
-EnvironmentFile=/home/aah/{{ .AppName }}_env_values
-ExecStart=/home/aah/{{ .AppName }}/bin/{{ .AppName }} run --envprofile prod
+{{ if .Opts.User }}User={{ .Opts.User }}{{ else }}#User=aah{{ end }}
+{{ if .Opts.Group }}Group={{ .Opts.Group }}{{ else }}#Group=aah{{ end }}
+WorkingDirectory={{ .Opts.InstallDir }}
+EnvironmentFile={{ .Opts.EnvFile }}
+ExecStart={{ .Opts.InstallDir }}/bin/{{ .AppName }} run --envprofile {{ .Opts.EnvProfile }}
 ExecReload=/bin/kill -HUP $MAINPID
-Restart=on-failure
+Restart={{ .Opts.Restart }}{{ if .Opts.LimitNOFILE }}
+LimitNOFILE={{ .Opts.LimitNOFILE }}{{ end }}{{ if .Opts.ProtectSystem }}
+ProtectSystem={{ .Opts.ProtectSystem }}{{ if ne .Opts.ProtectSystem "false" }}
+ReadWritePaths={{ .Opts.InstallDir }}{{ end }}{{ end }}{{ if .Opts.NoNewPrivileges }}
+NoNewPrivileges=true{{ end }}
 
 [Install]
 WantedBy=multi-user.target
 `
 
+const aahLogrotateScriptTemplate = `# GENERATED BY aah CLI - Feel free to customization it.
+# FILE: {{ .FileName }}
+# DATE: {{ .CreateDate }}
+# DESC: aah application logrotate config for file log receiver
+
+{{ .LogPattern }} {
+    daily
+    rotate 14
+    missingok
+    notifempty
+    compress
+    delaycompress
+    copytruncate{{ if .Opts.User }}
+    su {{ .Opts.User }} {{ if .Opts.Group }}{{ .Opts.Group }}{{ else }}{{ .Opts.User }}{{ end }}{{ end }}
+}
+`
+
 const aahDockerDevScriptTemplate = `# GENERATED BY aah CLI - Feel free to customization it.
 # FILE: {{ .FileName }}
 # DATE: {{ .CreateDate }}
@@ -322,3 +985,234 @@ WORKDIR /aah/{{ .AppName }}
 CMD ["./bin/{{ .AppName }}", "run", "--envprofile", "prod"]
 EXPOSE 8080
 `
+
+const aahK8sDeploymentTemplate = `# GENERATED BY aah CLI - Feel free to customization it.
+# FILE: {{ .FileName }}
+# DATE: {{ .CreateDate }}
+# DESC: aah application Kubernetes Deployment
+
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .AppName }}{{ if .Namespace }}
+  namespace: {{ .Namespace }}{{ end }}
+  labels:
+    app: {{ .AppName }}
+spec:
+  replicas: {{ .Replicas }}
+  selector:
+    matchLabels:
+      app: {{ .AppName }}
+  template:
+    metadata:
+      labels:
+        app: {{ .AppName }}
+    spec:
+      containers:
+        - name: {{ .AppName }}
+          image: {{ .Image }}
+          command: ["/aah/{{ .AppName }}/bin/{{ .BinaryName }}"]
+          args: ["run", "--envprofile", "{{ .EnvProfile }}", "--config", "/etc/{{ .AppName }}/app.conf"]
+          ports:
+            - name: http
+              containerPort: {{ .Port }}
+          livenessProbe:{{ if .HealthPath }}
+            httpGet:
+              path: {{ .HealthPath }}
+              port: http{{ if .SSL }}
+              scheme: HTTPS{{ end }}{{ else }}
+            tcpSocket:
+              port: http{{ end }}
+            initialDelaySeconds: 10
+            periodSeconds: 15
+            timeoutSeconds: 3
+            failureThreshold: 3
+          readinessProbe:{{ if .HealthPath }}
+            httpGet:
+              path: {{ .HealthPath }}
+              port: http{{ if .SSL }}
+              scheme: HTTPS{{ end }}{{ else }}
+            tcpSocket:
+              port: http{{ end }}
+            initialDelaySeconds: 5
+            periodSeconds: 10
+            timeoutSeconds: 3
+            failureThreshold: 3
+          resources:
+            requests:
+              cpu: 100m
+              memory: 128Mi
+            limits:
+              cpu: 500m
+              memory: 256Mi
+          volumeMounts:
+            - name: config
+              mountPath: /etc/{{ .AppName }}
+              readOnly: true
+      volumes:
+        - name: config
+          configMap:
+            name: {{ .AppName }}-config
+`
+
+const aahK8sServiceTemplate = `# GENERATED BY aah CLI - Feel free to customization it.
+# FILE: {{ .FileName }}
+# DATE: {{ .CreateDate }}
+# DESC: aah application Kubernetes Service
+
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{ .AppName }}{{ if .Namespace }}
+  namespace: {{ .Namespace }}{{ end }}
+  labels:
+    app: {{ .AppName }}
+spec:
+  type: ClusterIP
+  selector:
+    app: {{ .AppName }}
+  ports:
+    - name: http
+      port: {{ if .SSL }}443{{ else }}80{{ end }}
+      targetPort: http
+`
+
+const aahK8sIngressTemplate = `# GENERATED BY aah CLI - Feel free to customization it.
+# FILE: {{ .FileName }}
+# DATE: {{ .CreateDate }}
+# DESC: aah application Kubernetes Ingress
+
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: {{ .AppName }}{{ if .Namespace }}
+  namespace: {{ .Namespace }}{{ end }}
+  labels:
+    app: {{ .AppName }}{{ if .SSL }}
+  annotations:
+    nginx.ingress.kubernetes.io/backend-protocol: "HTTPS"{{ end }}
+spec:
+  rules:
+    - host: {{ .Host }}
+      http:
+        paths:
+          - path: /
+            pathType: Prefix
+            backend:
+              service:
+                name: {{ .AppName }}
+                port:
+                  name: http
+`
+
+const aahK8sConfigMapTemplate = `# GENERATED BY aah CLI - Feel free to customization it.
+# FILE: {{ .FileName }}
+# DATE: {{ .CreateDate }}
+# DESC: aah application Kubernetes ConfigMap, it carries the environment
+# profile '{{ .EnvProfile }}' configuration and passed to application via '--config'.
+
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .AppName }}-config{{ if .Namespace }}
+  namespace: {{ .Namespace }}{{ end }}
+  labels:
+    app: {{ .AppName }}
+data:
+  app.conf: |
+{{ .EnvConfig }}
+`
+
+const aahKustomizeBaseTemplate = `# GENERATED BY aah CLI - Feel free to customization it.
+# FILE: {{ .FileName }}
+# DATE: {{ .CreateDate }}
+# DESC: aah application Kustomize base
+
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+{{ if .Namespace }}namespace: {{ .Namespace }}
+{{ end }}resources:
+  - deployment.yaml
+  - service.yaml
+  - ingress.yaml
+
+configMapGenerator:
+  - name: {{ .AppName }}-config
+    files:
+      - app.conf
+`
+
+const aahKustomizeOverlayTemplate = `# GENERATED BY aah CLI - Feel free to customization it.
+# FILE: {{ .FileName }}
+# DATE: {{ .CreateDate }}
+# DESC: aah application Kustomize overlay for environment profile '{{ .OverlayProfile }}'
+
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+  - ../../base
+
+configMapGenerator:
+  - name: {{ .AppName }}-config
+    behavior: replace
+    files:
+      - app.conf
+
+patches:
+  - target:
+      kind: Deployment
+      name: {{ .AppName }}
+    patch: |-
+      - op: replace
+        path: /spec/template/spec/containers/0/args
+        value: ["run", "--envprofile", "{{ .OverlayProfile }}", "--config", "/etc/{{ .AppName }}/app.conf"]
+`
+
+const aahComposeScriptTemplate = `# GENERATED BY aah CLI - Feel free to customization it.
+# FILE: {{ .FileName }}
+# DATE: {{ .CreateDate }}
+# DESC: aah application docker compose for development, source is mounted
+# into the container and 'aah run' hot reloads on changes.
+
+services:
+  app:
+    build:
+      context: .
+      dockerfile: Dockerfile.dev
+    command: aah run --envprofile dev
+    working_dir: /aah/{{ .AppName }}
+    volumes:
+      - ./:/aah/{{ .AppName }}
+      - go-mod-cache:/go/pkg/mod
+    ports:
+      - "{{ .Port }}:{{ .Port }}"{{ if .Sidecars }}
+    depends_on:{{ range .Sidecars }}
+      - {{ .Name }}{{ end }}{{ end }}
+{{ range .Sidecars }}
+  {{ .Name }}:
+    image: {{ .Image }}
+    ports:
+      - "{{ .Port }}:{{ .Port }}"
+{{ end }}
+volumes:
+  go-mod-cache:
+`
+
+const aahDevContainerTemplate = `{
+  "name": "{{ .AppName }}",{{ if .UseCompose }}
+  "dockerComposeFile": ["../docker-compose.yml"],
+  "service": "app",
+  "workspaceFolder": "/aah/{{ .AppName }}",
+  "shutdownAction": "stopCompose",{{ else }}
+  "image": "golang:latest",
+  "workspaceFolder": "/aah/{{ .AppName }}",
+  "workspaceMount": "source=${localWorkspaceFolder},target=/aah/{{ .AppName }},type=bind",{{ end }}
+  "forwardPorts": [{{ .Port }}],
+  "postCreateCommand": "curl -s https://aahframework.org/install-cli | bash && go mod download",
+  "customizations": {
+    "vscode": {
+      "extensions": ["golang.go"]
+    }
+  }
+}
+`
@@ -0,0 +1,1463 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"aahframe.work"
+	"aahframe.work/ainsp"
+	"aahframe.work/config"
+	"aahframe.work/console"
+	"aahframe.work/essentials"
+	"aahframe.work/router"
+)
+
+var (
+	goIdentRegex   = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+	apiVersionRegx = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+)
+
+// codeGenAppInfo method returns the application base directory and import
+// path for code generation subcommands.
+func codeGenAppInfo(c *console.Context) (string, string) {
+	if !isAahProject() {
+		logFatalf("Please go to aah application base directory and run '%s'.", strings.Join(os.Args, " "))
+	}
+
+	importPath := appImportPath(c)
+	if ess.IsStrEmpty(importPath) {
+		logFatalf("Unable to infer import path, ensure you're in the aah application base directory")
+	}
+	chdirIfRequired(importPath)
+	baseDir, err := os.Getwd()
+	if err != nil {
+		logFatal(err)
+	}
+
+	projectCfg := aahProjectCfg(baseDir)
+	cliLog = initCLILogger(projectCfg)
+	return baseDir, importPath
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Generate Subcommand - Controller
+//___________________________________
+
+// controllerInfo struct holds the details of controller or websocket being
+// generated.
+type controllerInfo struct {
+	Name       string
+	TypeName   string
+	Package    string
+	APIVersion string
+	IsAPI      bool
+	WebSocket  bool
+	Actions    []*actionInfo
+	file       string
+}
+
+// actionInfo struct holds the details of controller action and its route.
+type actionInfo struct {
+	Name     string
+	Method   string
+	Path     string
+	RouteKey string
+	HasID    bool
+	Created  bool
+}
+
+// RouteController method returns controller value used in 'routes.conf'.
+func (ci *controllerInfo) RouteController() string {
+	if ess.IsStrEmpty(ci.APIVersion) {
+		return ci.TypeName
+	}
+	return ci.APIVersion + "/" + ci.TypeName
+}
+
+func generateControllerAction(c *console.Context) error {
+	name := strings.TrimSpace(c.Args().First())
+	if ess.IsStrEmpty(name) {
+		_ = console.ShowSubcommandHelp(c)
+		return nil
+	}
+	baseDir, importPath := codeGenAppInfo(c)
+
+	ci, err := newControllerInfo(baseDir, name, c.String("actions"), c.String("api"), c.Bool("websocket"))
+	if err != nil {
+		logFatal(err)
+	}
+	if !ci.WebSocket && ess.IsStrEmpty(ci.APIVersion) {
+		ci.IsAPI = appTypeOf(baseDir) == typeAPI
+	}
+
+	if checkAndConfirmOverwrite(c, ci.file) {
+		return nil
+	}
+
+	tmpl := codeControllerTemplate
+	if ci.WebSocket {
+		tmpl = codeWebSocketTemplate
+	}
+	files := []codeFile{{dst: ci.file, tmpl: tmpl}}
+	if err = generateCode(baseDir, importPath, files, ci, c.String("domain"), ci.routes()); err != nil {
+		logFatal(err)
+	}
+
+	cliLog.Infof("Generated '%s' at '%s'\n", ci.TypeName, ci.file)
+	if !ci.WebSocket && !ci.IsAPI {
+		cliLog.Infof("What's next, create the view files for actions at 'views/pages/%s/'\n", strings.ToLower(ci.Name))
+	}
+	return nil
+}
+
+func newControllerInfo(baseDir, name, actions, apiVersion string, websocket bool) (*controllerInfo, error) {
+	suffix, pkg := "Controller", "controllers"
+	if websocket {
+		suffix, pkg = "WebSocket", "websockets"
+	}
+
+	name = toExportedName(strings.TrimSuffix(name, suffix))
+	if !goIdentRegex.MatchString(name) {
+		return nil, fmt.Errorf("Invalid name '%s', it should be a valid Go identifier", name)
+	}
+
+	ci := &controllerInfo{
+		Name:      name,
+		TypeName:  name + suffix,
+		Package:   pkg,
+		WebSocket: websocket,
+	}
+	fileDir := filepath.Join(baseDir, "app", pkg)
+
+	apiVersion = strings.ToLower(strings.TrimSpace(apiVersion))
+	if !ess.IsStrEmpty(apiVersion) {
+		if websocket {
+			return nil, errors.New("Flag '--api' is not applicable with '--websocket'")
+		}
+		if !apiVersionRegx.MatchString(apiVersion) {
+			return nil, fmt.Errorf("Invalid API version '%s', it is used as Go package name", apiVersion)
+		}
+		ci.APIVersion, ci.Package, ci.IsAPI = apiVersion, apiVersion, true
+		fileDir = filepath.Join(fileDir, apiVersion)
+	}
+	ci.file = filepath.Join(fileDir, toSnakeCase(name)+".go")
+
+	if ess.IsStrEmpty(actions) {
+		actions = "Index"
+		if websocket {
+			actions = "Handle"
+		}
+	}
+	for _, a := range strings.Split(actions, ",") {
+		a = toExportedName(strings.TrimSpace(a))
+		if ess.IsStrEmpty(a) {
+			continue
+		}
+		if !goIdentRegex.MatchString(a) {
+			return nil, fmt.Errorf("Invalid action name '%s', it should be a valid Go identifier", a)
+		}
+		ci.Actions = append(ci.Actions, ci.newActionInfo(a))
+	}
+	return ci, nil
+}
+
+// newActionInfo method maps the action name to the HTTP method and path
+// by REST conventions, rest of the actions mapped to 'GET <resource>/<action>'.
+func (ci *controllerInfo) newActionInfo(name string) *actionInfo {
+	resource := toSnakeCase(ci.Name)
+	basePath := "/" + resource
+	if ci.WebSocket {
+		basePath = "/ws/" + resource
+	} else if !ess.IsStrEmpty(ci.APIVersion) {
+		basePath = "/" + ci.APIVersion + basePath
+	}
+
+	routeKey := resource + "_" + toSnakeCase(name)
+	if !ess.IsStrEmpty(ci.APIVersion) {
+		routeKey = ci.APIVersion + "_" + routeKey
+	}
+
+	ai := &actionInfo{Name: name, Method: "GET", Path: basePath, RouteKey: routeKey}
+	if ci.WebSocket {
+		ai.Method = "WS"
+		if name != "Handle" {
+			ai.Path = path.Join(basePath, toSnakeCase(name))
+		}
+		return ai
+	}
+
+	switch name {
+	case "Index", "List":
+	case "Show", "Get":
+		ai.Path, ai.HasID = path.Join(basePath, ":id"), true
+	case "Create":
+		ai.Method, ai.Created = "POST", true
+	case "Update":
+		ai.Method, ai.Path, ai.HasID = "PUT", path.Join(basePath, ":id"), true
+	case "Delete", "Destroy":
+		ai.Method, ai.Path, ai.HasID = "DELETE", path.Join(basePath, ":id"), true
+	case "New":
+		ai.Path = path.Join(basePath, "new")
+	case "Edit":
+		ai.Path, ai.HasID = path.Join(basePath, ":id", "edit"), true
+	default:
+		ai.Path = path.Join(basePath, toSnakeCase(name))
+	}
+	return ai
+}
+
+func (ci *controllerInfo) routes() []*routeEntry {
+	routes := make([]*routeEntry, 0, len(ci.Actions))
+	for _, a := range ci.Actions {
+		routes = append(routes, &routeEntry{
+			Key:        a.RouteKey,
+			Path:       a.Path,
+			Method:     a.Method,
+			Controller: ci.RouteController(),
+			Action:     a.Name,
+		})
+	}
+	return routes
+}
+
+// appTypeOf method returns the application type configured in 'aah.conf'.
+func appTypeOf(baseDir string) string {
+	cfg, err := config.LoadFile(filepath.Join(baseDir, "config", "aah.conf"))
+	if err != nil {
+		return typeWeb
+	}
+	return cfg.StringDefault("type", typeWeb)
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Generate Subcommand - Resource
+//___________________________________
+
+// resourceFieldTypes maps the field type given in command line to Go type.
+var resourceFieldTypes = map[string]string{
+	"string":  "string",
+	"text":    "string",
+	"int":     "int",
+	"int64":   "int64",
+	"uint":    "uint",
+	"float":   "float64",
+	"float64": "float64",
+	"bool":    "bool",
+	"time":    "time.Time",
+}
+
+// resourceInfo struct holds the details of REST resource being generated.
+type resourceInfo struct {
+	*controllerInfo
+	ImportPath     string
+	Fields         []*resourceField
+	ViewEngine     string
+	TmplDelimLeft  string
+	TmplDelimRight string
+}
+
+// resourceField struct holds the resource model field details.
+type resourceField struct {
+	Name      string
+	Type      string
+	InputType string
+}
+
+// HasTimeField method returns true if any of the field is 'time.Time'.
+func (r *resourceInfo) HasTimeField() bool {
+	for _, f := range r.Fields {
+		if f.Type == "time.Time" {
+			return true
+		}
+	}
+	return false
+}
+
+// ListPath method returns the route path of resource list action.
+func (r *resourceInfo) ListPath() string {
+	return r.Actions[0].Path
+}
+
+func generateResourceAction(c *console.Context) error {
+	args := c.Args()
+	name := strings.TrimSpace(args.First())
+	if ess.IsStrEmpty(name) {
+		_ = console.ShowSubcommandHelp(c)
+		return nil
+	}
+	baseDir, importPath := codeGenAppInfo(c)
+
+	ci, err := newControllerInfo(baseDir, name, "List,Get,Create,Update,Delete", c.String("api"), false)
+	if err != nil {
+		logFatal(err)
+	}
+	fields, err := parseResourceFields(args.Tail())
+	if err != nil {
+		logFatal(err)
+	}
+
+	appCfg, _ := config.LoadFile(filepath.Join(baseDir, "config", "aah.conf"))
+	if appCfg == nil {
+		appCfg = config.NewEmpty()
+	}
+	if ess.IsStrEmpty(ci.APIVersion) {
+		ci.IsAPI = appCfg.StringDefault("type", typeWeb) == typeAPI
+	}
+
+	r := &resourceInfo{
+		controllerInfo: ci,
+		ImportPath:     importPath,
+		Fields:         fields,
+		ViewEngine:     appCfg.StringDefault("view.engine", "go"),
+		TmplDelimLeft:  "{{",
+		TmplDelimRight: "}}",
+	}
+
+	files := []codeFile{
+		{dst: filepath.Join(baseDir, "app", "models", toSnakeCase(r.Name)+".go"), tmpl: resourceModelTemplate},
+		{dst: ci.file, tmpl: resourceControllerTemplate},
+	}
+	if !r.IsAPI {
+		viewExt := appCfg.StringDefault("view.ext", ".html")
+		listTmpl, getTmpl := resourceListViewTemplate, resourceGetViewTemplate
+		if r.ViewEngine == "pug" {
+			viewExt = appCfg.StringDefault("view.ext", ".pug")
+			listTmpl, getTmpl = resourceListPugTemplate, resourceGetPugTemplate
+		} else if r.ViewEngine != "go" {
+			logFatalf("View engine '%s' is not supported by 'generate resource', supported engines are 'go', 'pug'", r.ViewEngine)
+		}
+		viewDir := filepath.Join(baseDir, "views", "pages", strings.ToLower(r.Name))
+		files = append(files,
+			codeFile{dst: filepath.Join(viewDir, "list"+viewExt), tmpl: listTmpl},
+			codeFile{dst: filepath.Join(viewDir, "get"+viewExt), tmpl: getTmpl},
+		)
+	}
+
+	for _, f := range files {
+		if checkAndConfirmOverwrite(c, f.dst) {
+			return nil
+		}
+	}
+
+	if err = generateCode(baseDir, importPath, files, r, c.String("domain"), ci.routes()); err != nil {
+		logFatal(err)
+	}
+
+	cliLog.Infof("Generated resource '%s' at\n\t%s\n", r.Name, strings.Join(codeFilePaths(files), "\n\t"))
+	cliLog.Infof("What's next, replace the in-memory store in 'app/models/%s.go' with your data store\n", toSnakeCase(r.Name))
+	return nil
+}
+
+// parseResourceFields method parses the fields given as 'name:type', type is
+// optional and defaults to 'string'.
+func parseResourceFields(args []string) ([]*resourceField, error) {
+	var fields []*resourceField
+	names := make(map[string]bool)
+	for _, arg := range args {
+		parts := strings.SplitN(arg, ":", 2)
+		name := toExportedName(parts[0])
+		if !goIdentRegex.MatchString(name) {
+			return nil, fmt.Errorf("Invalid field name '%s', it should be a valid Go identifier", parts[0])
+		}
+		if name == "ID" || name == "Id" || names[name] {
+			return nil, fmt.Errorf("Field '%s' is duplicate or reserved", parts[0])
+		}
+		names[name] = true
+
+		fieldType := "string"
+		if len(parts) == 2 {
+			fieldType = strings.ToLower(strings.TrimSpace(parts[1]))
+		}
+		goType, found := resourceFieldTypes[fieldType]
+		if !found {
+			return nil, fmt.Errorf("Unsupported field type '%s', supported types are string, text, int, int64, uint, float, float64, bool, time", fieldType)
+		}
+
+		f := &resourceField{Name: name, Type: goType, InputType: "text"}
+		switch fieldType {
+		case "int", "int64", "uint", "float", "float64":
+			f.InputType = "number"
+		case "bool":
+			f.InputType = "checkbox"
+		case "time":
+			f.InputType = "datetime-local"
+		case "text":
+			f.InputType = "textarea"
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+func codeFilePaths(files []codeFile) []string {
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		paths = append(paths, f.dst)
+	}
+	return paths
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Generate Subcommand - Middleware
+//___________________________________
+
+func generateMiddlewareAction(c *console.Context) error {
+	name := toExportedName(strings.TrimSpace(c.Args().First()))
+	if ess.IsStrEmpty(name) {
+		_ = console.ShowSubcommandHelp(c)
+		return nil
+	}
+	if !strings.HasSuffix(name, "Middleware") {
+		name += "Middleware"
+	}
+	if !goIdentRegex.MatchString(name) {
+		logFatalf("Invalid name '%s', it should be a valid Go identifier", name)
+	}
+	baseDir, importPath := codeGenAppInfo(c)
+
+	destFile := filepath.Join(baseDir, "app", "middleware", toSnakeCase(strings.TrimSuffix(name, "Middleware"))+".go")
+	if checkAndConfirmOverwrite(c, destFile) {
+		return nil
+	}
+
+	err := generateAndEditInit(baseDir, destFile, codeMiddlewareTemplate, map[string]interface{}{"Name": name},
+		func(g *goSrcFile) error {
+			g.addImport(importPath + "/app/middleware")
+			_, err := g.addCallArg("Middlewares", "middleware."+name, "aah.ActionMiddleware")
+			return err
+		})
+	if err != nil {
+		logFatal(err)
+	}
+
+	cliLog.Infof("Generated middleware '%s' at '%s' and registered in 'app/init.go'\n", name, destFile)
+	return nil
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Generate Subcommand - Event Handler
+//___________________________________
+
+// appEvents maps the supported event names to its registration method.
+var appEvents = map[string]string{
+	"OnInit":         "aah.App().OnInit",
+	"OnStart":        "aah.App().OnStart",
+	"OnPreShutdown":  "aah.App().OnPreShutdown",
+	"OnPostShutdown": "aah.App().OnPostShutdown",
+	"OnRequest":      "aah.App().HTTPEngine().OnRequest",
+	"OnPreReply":     "aah.App().HTTPEngine().OnPreReply",
+	"OnPostReply":    "aah.App().HTTPEngine().OnPostReply",
+	"OnPreAuth":      "aah.App().HTTPEngine().OnPreAuth",
+	"OnPostAuth":     "aah.App().HTTPEngine().OnPostAuth",
+}
+
+func generateEventHandlerAction(c *console.Context) error {
+	name := toExportedName(strings.TrimSpace(c.Args().First()))
+	if ess.IsStrEmpty(name) {
+		_ = console.ShowSubcommandHelp(c)
+		return nil
+	}
+	if !goIdentRegex.MatchString(name) {
+		logFatalf("Invalid name '%s', it should be a valid Go identifier", name)
+	}
+
+	var event string
+	for e := range appEvents {
+		if strings.EqualFold(e, strings.TrimSpace(c.String("event"))) {
+			event = e
+		}
+	}
+	if ess.IsStrEmpty(event) {
+		names := make([]string, 0, len(appEvents))
+		for e := range appEvents {
+			names = append(names, e)
+		}
+		sort.Strings(names)
+		logFatalf("Unsupported event '%s', supported events are %s", c.String("event"), strings.Join(names, ", "))
+	}
+	baseDir, _ := codeGenAppInfo(c)
+
+	data := map[string]interface{}{
+		"Name":        name,
+		"Event":       event,
+		"HTTPRequest": strings.Contains(appEvents[event], "HTTPEngine"),
+	}
+	var buf bytes.Buffer
+	if err := renderTmpl(&buf, codeEventHandlerTemplate, data); err != nil {
+		logFatal(err)
+	}
+
+	err := generateAndEditInit(baseDir, "", "", nil, func(g *goSrcFile) error {
+		if g.hasFunc(name) {
+			return fmt.Errorf("Func '%s' already exists in 'app/init.go'", name)
+		}
+		g.appendDecl(buf.String())
+		return g.addInitStmts(fmt.Sprintf("%s(%s)", appEvents[event], name))
+	})
+	if err != nil {
+		logFatal(err)
+	}
+
+	cliLog.Infof("Generated event handler '%s' for '%s' in 'app/init.go'\n", name, event)
+	return nil
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Generate Subcommand - Auth Provider
+//___________________________________
+
+// authProviderTypes maps the auth provider type to its type name suffix and
+// template.
+var authProviderTypes = map[string]struct {
+	suffix string
+	tmpl   string
+}{
+	"authenticator": {suffix: "Authenticator", tmpl: codeAuthenticatorTemplate},
+	"principal":     {suffix: "PrincipalProvider", tmpl: codePrincipalTemplate},
+	"authorizer":    {suffix: "Authorizer", tmpl: codeAuthorizerTemplate},
+}
+
+func generateAuthProviderAction(c *console.Context) error {
+	name := toExportedName(strings.TrimSpace(c.Args().First()))
+	if ess.IsStrEmpty(name) {
+		_ = console.ShowSubcommandHelp(c)
+		return nil
+	}
+
+	providerType := strings.ToLower(strings.TrimSpace(c.String("type")))
+	pt, found := authProviderTypes[providerType]
+	if !found {
+		logFatalf("Unsupported auth provider type '%s', supported types are authenticator, principal, authorizer", providerType)
+	}
+	scheme := strings.TrimSpace(c.String("scheme"))
+	if ess.IsStrEmpty(scheme) {
+		logFatal("Auth scheme name is required, use '--scheme <name>' from 'security.auth_schemes'")
+	}
+
+	name = strings.TrimSuffix(name, pt.suffix) + pt.suffix
+	if !goIdentRegex.MatchString(name) {
+		logFatalf("Invalid name '%s', it should be a valid Go identifier", name)
+	}
+	baseDir, _ := codeGenAppInfo(c)
+
+	destFile := filepath.Join(baseDir, "app", "security", toSnakeCase(name)+".go")
+	if checkAndConfirmOverwrite(c, destFile) {
+		return nil
+	}
+	secFile := filepath.Join(baseDir, "config", "security.conf")
+	secSrc, err := ioutil.ReadFile(secFile)
+	if err != nil {
+		logFatal(err)
+	}
+
+	data := map[string]interface{}{"Name": name, "Scheme": scheme}
+	destSnapshot := snapshotFile(destFile)
+	if err = generateAndEditInit(baseDir, destFile, pt.tmpl, data, nil); err != nil {
+		logFatal(err)
+	}
+
+	// security.auth_schemes.<name>.<type> = "security/<Name>"
+	cfgValue := "security/" + name
+	newSecSrc, err := setConfKey(secSrc, []string{"security", "auth_schemes", scheme}, providerType, cfgValue)
+	if err == nil {
+		err = ioutil.WriteFile(secFile, newSecSrc, permRWRWRW)
+	}
+	if err == nil {
+		err = verifyConfKey(secFile, fmt.Sprintf("security.auth_schemes.%s.%s", scheme, providerType), cfgValue)
+	}
+	if err != nil {
+		_ = ioutil.WriteFile(secFile, secSrc, permRWRWRW)
+		destSnapshot.Restore()
+		logFatalf("Unable to configure auth provider, changes are rolled back: %s", err)
+	}
+
+	cliLog.Infof("Generated %s '%s' at '%s'", providerType, name, destFile)
+	cliLog.Infof("Configured 'security.auth_schemes.%s.%s = \"%s\"' in '%s'\n", scheme, providerType, cfgValue, secFile)
+	return nil
+}
+
+func verifyConfKey(filename, key, expected string) error {
+	cfg, err := config.LoadFile(filename)
+	if err != nil {
+		return err
+	}
+	if v := cfg.StringDefault(key, ""); v != expected {
+		return fmt.Errorf("config key '%s' value is '%s', expected '%s'", key, v, expected)
+	}
+	return nil
+}
+
+// generateAndEditInit method renders the Go source file if given and edits the
+// 'app/init.go' via given func. On failure changes are rolled back.
+func generateAndEditInit(baseDir, destFile, tmpl string, data interface{}, edit func(g *goSrcFile) error) error {
+	var destSnapshot *fileSnapshot
+	if !ess.IsStrEmpty(destFile) {
+		destSnapshot = snapshotFile(destFile)
+		var buf bytes.Buffer
+		if err := renderTmpl(&buf, tmpl, data); err != nil {
+			return fmt.Errorf("Unable to generate '%s': %s", destFile, err)
+		}
+		src, err := format.Source(buf.Bytes())
+		if err != nil {
+			return fmt.Errorf("Unable to generate '%s', format source error: %s", destFile, err)
+		}
+		if err = ess.MkDirAll(filepath.Dir(destFile), permRWXRXRX); err != nil {
+			return err
+		}
+		if err = ioutil.WriteFile(destFile, src, permRWRWRW); err != nil {
+			destSnapshot.Restore()
+			return err
+		}
+		_ = ess.ApplyFileMode(destFile, permRWRWRW)
+	}
+	if edit == nil {
+		return nil
+	}
+
+	rollback := func() {
+		if destSnapshot != nil {
+			destSnapshot.Restore()
+		}
+	}
+	g, err := parseGoSrcFile(filepath.Join(baseDir, "app", "init.go"))
+	if err == nil {
+		err = edit(g)
+	}
+	if err == nil {
+		// init.go is written only if all the edits are valid
+		err = g.save()
+	}
+	if err != nil {
+		rollback()
+		return err
+	}
+	return nil
+}
+
+// fileSnapshot struct holds the file content before it gets overwritten by
+// code generation, it's used to rollback the changes.
+type fileSnapshot struct {
+	filename string
+	content  []byte
+	existed  bool
+}
+
+func snapshotFile(filename string) *fileSnapshot {
+	s := &fileSnapshot{filename: filename}
+	if b, err := ioutil.ReadFile(filename); err == nil {
+		s.content, s.existed = b, true
+	}
+	return s
+}
+
+// Restore method writes back the original content, file created by the
+// code generation is removed.
+func (s *fileSnapshot) Restore() {
+	if s.existed {
+		_ = ioutil.WriteFile(s.filename, s.content, permRWRWRW)
+		return
+	}
+	_ = os.Remove(s.filename)
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Code generation and validation
+//___________________________________
+
+// codeFile struct holds the destination file and its template.
+type codeFile struct {
+	dst  string
+	tmpl string
+}
+
+// generateCode method renders the files and appends the routes into
+// 'config/routes.conf'. Then result is validated with the same Go AST
+// inspection used by compile, on failure changes are rolled back.
+func generateCode(baseDir, importPath string, files []codeFile, data interface{}, domain string, routes []*routeEntry) error {
+	contents := make([][]byte, len(files))
+	for i, f := range files {
+		var buf bytes.Buffer
+		if err := renderTmpl(&buf, f.tmpl, data); err != nil {
+			return fmt.Errorf("Unable to generate '%s': %s", f.dst, err)
+		}
+		contents[i] = buf.Bytes()
+		if filepath.Ext(f.dst) == ".go" {
+			src, err := format.Source(contents[i])
+			if err != nil {
+				return fmt.Errorf("Unable to generate '%s', format source error: %s", f.dst, err)
+			}
+			contents[i] = src
+		}
+	}
+
+	routesFile := filepath.Join(baseDir, "config", "routes.conf")
+	routesSrc, err := ioutil.ReadFile(routesFile)
+	if err != nil {
+		return err
+	}
+
+	// existing files content for rollback
+	existing := make(map[string][]byte)
+	for _, f := range files {
+		if b, err := ioutil.ReadFile(f.dst); err == nil {
+			existing[f.dst] = b
+		}
+	}
+	rollback := func() {
+		_ = ioutil.WriteFile(routesFile, routesSrc, permRWRWRW)
+		for _, f := range files {
+			if b, found := existing[f.dst]; found {
+				_ = ioutil.WriteFile(f.dst, b, permRWRWRW)
+			} else {
+				_ = os.Remove(f.dst)
+			}
+		}
+	}
+
+	for i, f := range files {
+		if err = ess.MkDirAll(filepath.Dir(f.dst), permRWXRXRX); err == nil {
+			err = ioutil.WriteFile(f.dst, contents[i], permRWRWRW)
+		}
+		if err != nil {
+			rollback()
+			return err
+		}
+		_ = ess.ApplyFileMode(f.dst, permRWRWRW)
+	}
+
+	newRoutesSrc, added, err := addRoutes(routesSrc, domain, routes)
+	if err != nil {
+		rollback()
+		return err
+	}
+	if err = ioutil.WriteFile(routesFile, newRoutesSrc, permRWRWRW); err != nil {
+		rollback()
+		return err
+	}
+	for _, r := range added {
+		cliLog.Infof("Route '%s' [%s %s] added into '%s'", r.Key, r.Method, r.Path, routesFile)
+	}
+
+	if err = validateAppCode(baseDir, importPath); err != nil {
+		rollback()
+		return fmt.Errorf("Generated code validation failed, changes are rolled back:\n\t%s", err)
+	}
+	return nil
+}
+
+// validateAppCode method initializes the application with updated routes and
+// inspects the controllers and websockets same as 'compileApp'.
+func validateAppCode(baseDir, importPath string) error {
+	app := aah.App()
+	if err := app.InitForCLI(importPath); err != nil {
+		return err
+	}
+
+	projectCfg := aahProjectCfg(baseDir)
+	excludes, _ := projectCfg.StringList("build.ast_excludes")
+	appCodeDir := filepath.Join(baseDir, "app")
+
+	var errMsgs []string
+	for _, v := range []struct {
+		dir     string
+		actions map[string]map[string]uint8
+	}{
+		{dir: filepath.Join(appCodeDir, "controllers"), actions: app.Router().RegisteredActions()},
+		{dir: filepath.Join(appCodeDir, "websockets"), actions: app.Router().RegisteredWSActions()},
+	} {
+		if !ess.IsFileExists(v.dir) {
+			continue
+		}
+		prg, errs := ainsp.Inspect(v.dir, importPath, ess.Excludes(excludes), v.actions)
+		for _, e := range errs {
+			errMsgs = append(errMsgs, e.Error())
+		}
+		if prg == nil {
+			continue
+		}
+		for c, m := range prg.RegisteredActions {
+			for a, s := range m {
+				if s == 1 && !router.IsDefaultAction(a) {
+					errMsgs = append(errMsgs, fmt.Sprintf("%s.%s is configured in 'routes.conf', however not implemented", c, a))
+				}
+			}
+		}
+	}
+
+	if len(errMsgs) > 0 {
+		return errors.New(strings.Join(errMsgs, "\n\t"))
+	}
+	return nil
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// routes.conf editing
+//___________________________________
+
+// routeEntry struct holds the route definition to be added into
+// 'routes.conf'.
+type routeEntry struct {
+	Key        string
+	Path       string
+	Method     string
+	Controller string
+	Action     string
+	Auth       string
+}
+
+// addRoutes method inserts the routes at the end of 'routes' block of given
+// domain, empty domain means first domain. Existing content is not reformatted
+// and routes already exists by key are skipped.
+func addRoutes(src []byte, domain string, routes []*routeEntry) ([]byte, []*routeEntry, error) {
+	dOpen, dClose, found := confBlock(src, 0, len(src), "domains")
+	if !found {
+		return nil, nil, errors.New("routes.conf: 'domains' block not found")
+	}
+	dmOpen, dmClose, found := confBlock(src, dOpen+1, dClose, domain)
+	if !found {
+		return nil, nil, fmt.Errorf("routes.conf: domain '%s' not found", domain)
+	}
+	rOpen, rClose, found := confBlock(src, dmOpen+1, dmClose, "routes")
+	if !found {
+		return nil, nil, errors.New("routes.conf: 'routes' block not found in the domain")
+	}
+
+	// indentation is inferred from the closing brace of 'routes' block
+	lineStart := bytes.LastIndexByte(src[:rClose], '\n') + 1
+	indent := string(src[lineStart:rClose])
+	if !ess.IsStrEmpty(indent) {
+		indent, lineStart = "", rClose
+	}
+	inner := indent + "  "
+
+	var buf bytes.Buffer
+	var added []*routeEntry
+	for _, r := range routes {
+		if _, _, exists := confBlock(src, rOpen+1, rClose, r.Key); exists {
+			cliLog.Warnf("Route '%s' already exists in 'routes.conf', skipped", r.Key)
+			continue
+		}
+		fmt.Fprintf(&buf, "\n%s%s {\n", inner, r.Key)
+		fmt.Fprintf(&buf, "%s  path = %q\n", inner, r.Path)
+		fmt.Fprintf(&buf, "%s  method = %q\n", inner, r.Method)
+		fmt.Fprintf(&buf, "%s  controller = %q\n", inner, r.Controller)
+		fmt.Fprintf(&buf, "%s  action = %q\n", inner, r.Action)
+		if !ess.IsStrEmpty(r.Auth) {
+			fmt.Fprintf(&buf, "%s  auth = %q\n", inner, r.Auth)
+		}
+		fmt.Fprintf(&buf, "%s}\n", inner)
+		added = append(added, r)
+	}
+
+	result := make([]byte, 0, len(src)+buf.Len())
+	result = append(result, src[:lineStart]...)
+	result = append(result, buf.Bytes()...)
+	result = append(result, src[lineStart:]...)
+	return result, added, nil
+}
+
+// setConfKey method sets the key value within the block path, block path is
+// created if not exists. Existing value is replaced in-place, otherwise key is
+// added at the end of the block.
+func setConfKey(src []byte, blockPath []string, key, value string) ([]byte, error) {
+	start, end := 0, len(src)
+	for i, name := range blockPath {
+		bOpen, bClose, found := confBlock(src, start, end, name)
+		if !found {
+			// create remaining blocks at the end of current block
+			lineStart := bytes.LastIndexByte(src[:end], '\n') + 1
+			indent := ""
+			if lineStart <= end && ess.IsStrEmpty(string(src[lineStart:end])) && start > 0 {
+				indent = string(src[lineStart:end]) + "  "
+			} else {
+				lineStart = end
+			}
+			var buf bytes.Buffer
+			if start == 0 {
+				buf.WriteString("\n")
+			}
+			for j, n := range blockPath[i:] {
+				fmt.Fprintf(&buf, "%s%s%s {\n", indent, strings.Repeat("  ", j), n)
+			}
+			fmt.Fprintf(&buf, "%s%s%s = %q\n", indent, strings.Repeat("  ", len(blockPath)-i), key, value)
+			for j := len(blockPath[i:]) - 1; j >= 0; j-- {
+				fmt.Fprintf(&buf, "%s%s}\n", indent, strings.Repeat("  ", j))
+			}
+			return insertBytes(src, lineStart, buf.Bytes()), nil
+		}
+		start, end = bOpen+1, bClose
+	}
+
+	if vStart, vEnd, found := confKeyValue(src, start, end, key); found {
+		result := append([]byte{}, src[:vStart]...)
+		result = append(result, []byte(fmt.Sprintf("%q", value))...)
+		return append(result, src[vEnd:]...), nil
+	}
+
+	lineStart := bytes.LastIndexByte(src[:end], '\n') + 1
+	indent := string(src[lineStart:end])
+	if !ess.IsStrEmpty(indent) {
+		return insertBytes(src, end, []byte(fmt.Sprintf("\n%s = %q\n", key, value))), nil
+	}
+	return insertBytes(src, lineStart, []byte(fmt.Sprintf("%s  %s = %q\n", indent, key, value))), nil
+}
+
+// confKeyValue method finds the value offsets of 'key = value' directly
+// within src[start:end], trailing comment is not part of the value.
+func confKeyValue(src []byte, start, end int, key string) (int, int, bool) {
+	depth := 0
+	for i := start; i < end; i++ {
+		c := src[i]
+		switch {
+		case c == '#' || (c == '/' && i+1 < end && src[i+1] == '/'):
+			for i < end && src[i] != '\n' {
+				i++
+			}
+		case c == '"':
+			for i++; i < end && src[i] != '"'; i++ {
+				if src[i] == '\\' {
+					i++
+				}
+			}
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+		case depth == 0 && bytes.HasPrefix(src[i:end], []byte(key)) && (i == start || isConfSpace(src[i-1])):
+			j := i + len(key)
+			for j < end && (src[j] == ' ' || src[j] == '\t') {
+				j++
+			}
+			if j < end && (src[j] == '=' || src[j] == ':') {
+				j++
+				for j < end && (src[j] == ' ' || src[j] == '\t') {
+					j++
+				}
+				vEnd := j
+				if vEnd < end && src[vEnd] == '"' {
+					for vEnd++; vEnd < end && src[vEnd] != '"'; vEnd++ {
+						if src[vEnd] == '\\' {
+							vEnd++
+						}
+					}
+					return j, vEnd + 1, true
+				}
+				for vEnd < end && src[vEnd] != '\n' && src[vEnd] != '#' {
+					vEnd++
+				}
+				return j, len(bytes.TrimRight(src[:vEnd], " \t\r")), true
+			}
+		}
+	}
+	return -1, -1, false
+}
+
+func isConfSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '{'
+}
+
+func insertBytes(src []byte, offset int, b []byte) []byte {
+	result := make([]byte, 0, len(src)+len(b))
+	result = append(result, src[:offset]...)
+	result = append(result, b...)
+	return append(result, src[offset:]...)
+}
+
+// confBlock method finds the block 'key { ... }' directly within src[start:end]
+// and returns offsets of its opening and closing brace. If key is empty first
+// block is returned. Comments and quoted strings are skipped.
+func confBlock(src []byte, start, end int, key string) (int, int, bool) {
+	depth, open := 0, -1
+	var ident, blockKey string
+	isIdentChar := func(c byte) bool {
+		return c == '_' || c == '-' || c == '.' || c == '*' ||
+			(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+	}
+
+	for i := start; i < end; i++ {
+		c := src[i]
+		switch {
+		case c == '#' || (c == '/' && i+1 < end && src[i+1] == '/'):
+			for i < end && src[i] != '\n' {
+				i++
+			}
+		case c == '"':
+			for i++; i < end && src[i] != '"'; i++ {
+				if src[i] == '\\' {
+					i++
+				}
+			}
+			ident = ""
+		case c == '{':
+			if depth == 0 {
+				open, blockKey = i, ident
+			}
+			depth++
+			ident = ""
+		case c == '}':
+			depth--
+			if depth == 0 && open > -1 && (ess.IsStrEmpty(key) || blockKey == key) {
+				return open, i, true
+			}
+			ident = ""
+		case isIdentChar(c):
+			if i == start || !isIdentChar(src[i-1]) {
+				ident = ""
+			}
+			ident += string(c)
+		}
+	}
+	return -1, -1, false
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Code Templates
+//___________________________________
+
+const codeControllerTemplate = `package {{ .Package }}
+
+import (
+	"aahframe.work"
+)
+
+// {{ .TypeName }} is to handle the '{{ .Name }}' requests.
+type {{ .TypeName }} struct {
+	*aah.Context
+}
+{{ range .Actions }}
+// {{ .Name }} method handles '{{ .Method }} {{ .Path }}'.
+func (c *{{ $.TypeName }}) {{ .Name }}({{ if .HasID }}id string{{ end }}) {
+	{{ if $.IsAPI -}}
+	c.Reply().{{ if .Created }}Created{{ else }}Ok{{ end }}().JSON(aah.Data{
+		"action": "{{ .Name }}",{{ if .HasID }}
+		"id": id,{{ end }}
+	})
+	{{- else -}}
+	c.Reply().{{ if .Created }}Created{{ else }}Ok{{ end }}().HTML(aah.Data{
+		"Title": "{{ $.Name }} - {{ .Name }}",{{ if .HasID }}
+		"ID": id,{{ end }}
+	})
+	{{- end }}
+}
+{{ end }}`
+
+const codeWebSocketTemplate = `package {{ .Package }}
+
+import (
+	"aahframe.work/ws"
+)
+
+// {{ .TypeName }} is to handle the '{{ .Name }}' WebSocket connections.
+type {{ .TypeName }} struct {
+	*ws.Context
+}
+{{ range .Actions }}
+// {{ .Name }} method handles '{{ .Method }} {{ .Path }}', it echoes the
+// text messages back to the client.
+func (w *{{ $.TypeName }}) {{ .Name }}() {
+	for {
+		str, err := w.ReadText()
+		if err != nil {
+			w.Log().Error(err)
+			return
+		}
+
+		if err := w.ReplyText(str); err != nil {
+			w.Log().Error(err)
+			return
+		}
+	}
+}
+{{ end }}`
+
+const resourceModelTemplate = `package models
+
+import (
+	"sort"
+	"sync"{{ if .HasTimeField }}
+	"time"{{ end }}
+)
+
+// {{ .Name }} is the model of '{{ .Name }}' resource.
+type {{ .Name }} struct {
+	ID int64 ` + "`" + `json:"id" bind:"id"` + "`" + `{{ range .Fields }}
+	{{ .Name }} {{ .Type }} ` + "`" + `json:"{{ snakecase .Name }}" bind:"{{ snakecase .Name }}"` + "`" + `{{ end }}
+}
+
+// {{ variablename (snakecase .Name) }}Store is the in-memory store of '{{ .Name }}' resource.
+//
+// TODO: Replace it with your data store.
+var {{ variablename (snakecase .Name) }}Store = struct {
+	sync.RWMutex
+	seq   int64
+	items map[int64]*{{ .Name }}
+}{items: make(map[int64]*{{ .Name }})}
+
+// List{{ .Name }} method returns all the {{ .Name }} ordered by ID.
+func List{{ .Name }}() []*{{ .Name }} {
+	{{ variablename (snakecase .Name) }}Store.RLock()
+	defer {{ variablename (snakecase .Name) }}Store.RUnlock()
+	list := make([]*{{ .Name }}, 0, len({{ variablename (snakecase .Name) }}Store.items))
+	for _, v := range {{ variablename (snakecase .Name) }}Store.items {
+		list = append(list, v)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// Get{{ .Name }} method returns the {{ .Name }} for given ID.
+func Get{{ .Name }}(id int64) (*{{ .Name }}, bool) {
+	{{ variablename (snakecase .Name) }}Store.RLock()
+	defer {{ variablename (snakecase .Name) }}Store.RUnlock()
+	v, found := {{ variablename (snakecase .Name) }}Store.items[id]
+	return v, found
+}
+
+// Create{{ .Name }} method stores the {{ .Name }} with new ID.
+func Create{{ .Name }}(v *{{ .Name }}) {
+	{{ variablename (snakecase .Name) }}Store.Lock()
+	defer {{ variablename (snakecase .Name) }}Store.Unlock()
+	{{ variablename (snakecase .Name) }}Store.seq++
+	v.ID = {{ variablename (snakecase .Name) }}Store.seq
+	{{ variablename (snakecase .Name) }}Store.items[v.ID] = v
+}
+
+// Update{{ .Name }} method replaces the {{ .Name }} for given ID, returns
+// false if not exists.
+func Update{{ .Name }}(id int64, v *{{ .Name }}) bool {
+	{{ variablename (snakecase .Name) }}Store.Lock()
+	defer {{ variablename (snakecase .Name) }}Store.Unlock()
+	if _, found := {{ variablename (snakecase .Name) }}Store.items[id]; !found {
+		return false
+	}
+	v.ID = id
+	{{ variablename (snakecase .Name) }}Store.items[id] = v
+	return true
+}
+
+// Delete{{ .Name }} method deletes the {{ .Name }} for given ID, returns
+// false if not exists.
+func Delete{{ .Name }}(id int64) bool {
+	{{ variablename (snakecase .Name) }}Store.Lock()
+	defer {{ variablename (snakecase .Name) }}Store.Unlock()
+	if _, found := {{ variablename (snakecase .Name) }}Store.items[id]; !found {
+		return false
+	}
+	delete({{ variablename (snakecase .Name) }}Store.items, id)
+	return true
+}
+`
+
+const resourceControllerTemplate = `package {{ .Package }}
+
+import (
+	"aahframe.work"
+
+	"{{ .ImportPath }}/app/models"
+)
+
+// {{ .TypeName }} is the REST controller of '{{ .Name }}' resource.
+type {{ .TypeName }} struct {
+	*aah.Context
+}
+{{ if .IsAPI }}
+// List method handles 'GET {{ .ListPath }}'.
+func (c *{{ .TypeName }}) List() {
+	c.Reply().Ok().JSON(models.List{{ .Name }}())
+}
+
+// Get method handles 'GET {{ .ListPath }}/:id'.
+func (c *{{ .TypeName }}) Get(id int64) {
+	v, found := models.Get{{ .Name }}(id)
+	if !found {
+		c.Reply().NotFound().JSON(aah.Data{"message": "{{ .Name }} not found"})
+		return
+	}
+	c.Reply().Ok().JSON(v)
+}
+
+// Create method handles 'POST {{ .ListPath }}'.
+func (c *{{ .TypeName }}) Create(v *models.{{ .Name }}) {
+	models.Create{{ .Name }}(v)
+	c.Reply().Created().JSON(v)
+}
+
+// Update method handles 'PUT {{ .ListPath }}/:id'.
+func (c *{{ .TypeName }}) Update(id int64, v *models.{{ .Name }}) {
+	if !models.Update{{ .Name }}(id, v) {
+		c.Reply().NotFound().JSON(aah.Data{"message": "{{ .Name }} not found"})
+		return
+	}
+	c.Reply().Ok().JSON(v)
+}
+
+// Delete method handles 'DELETE {{ .ListPath }}/:id'.
+func (c *{{ .TypeName }}) Delete(id int64) {
+	if !models.Delete{{ .Name }}(id) {
+		c.Reply().NotFound().JSON(aah.Data{"message": "{{ .Name }} not found"})
+		return
+	}
+	c.Reply().Ok().JSON(aah.Data{"message": "{{ .Name }} deleted"})
+}
+{{ else }}
+// List method handles 'GET {{ .ListPath }}'.
+func (c *{{ .TypeName }}) List() {
+	c.Reply().Ok().HTML(aah.Data{
+		"{{ .Name }}List": models.List{{ .Name }}(),
+	})
+}
+
+// Get method handles 'GET {{ .ListPath }}/:id'.
+func (c *{{ .TypeName }}) Get(id int64) {
+	v, found := models.Get{{ .Name }}(id)
+	if !found {
+		c.Reply().NotFound().Text("{{ .Name }} not found")
+		return
+	}
+	c.Reply().Ok().HTML(aah.Data{
+		"{{ .Name }}": v,
+	})
+}
+
+// Create method handles 'POST {{ .ListPath }}'.
+func (c *{{ .TypeName }}) Create(v *models.{{ .Name }}) {
+	models.Create{{ .Name }}(v)
+	c.Reply().Redirect(c.RouteURL("{{ (index .Actions 0).RouteKey }}"))
+}
+
+// Update method handles 'PUT {{ .ListPath }}/:id'.
+func (c *{{ .TypeName }}) Update(id int64, v *models.{{ .Name }}) {
+	if !models.Update{{ .Name }}(id, v) {
+		c.Reply().NotFound().Text("{{ .Name }} not found")
+		return
+	}
+	c.Reply().Redirect(c.RouteURL("{{ (index .Actions 0).RouteKey }}"))
+}
+
+// Delete method handles 'DELETE {{ .ListPath }}/:id'.
+func (c *{{ .TypeName }}) Delete(id int64) {
+	if !models.Delete{{ .Name }}(id) {
+		c.Reply().NotFound().Text("{{ .Name }} not found")
+		return
+	}
+	c.Reply().Redirect(c.RouteURL("{{ (index .Actions 0).RouteKey }}"))
+}
+{{ end }}`
+
+const resourceListViewTemplate = `{{ .TmplDelimLeft }} define "title" {{ .TmplDelimRight }}{{ .Name }} List{{ .TmplDelimLeft }} end {{ .TmplDelimRight }}
+
+{{ .TmplDelimLeft }} define "body" {{ .TmplDelimRight }}
+<h1>{{ .Name }} List</h1>
+<table>
+  <thead>
+    <tr>
+      <th>ID</th>{{ range .Fields }}
+      <th>{{ .Name }}</th>{{ end }}
+    </tr>
+  </thead>
+  <tbody>
+    {{ .TmplDelimLeft }} range .{{ .Name }}List {{ .TmplDelimRight }}
+    <tr>
+      <td><a href="{{ .ListPath }}/{{ .TmplDelimLeft }} .ID {{ .TmplDelimRight }}">{{ .TmplDelimLeft }} .ID {{ .TmplDelimRight }}</a></td>{{ range .Fields }}
+      <td>{{ $.TmplDelimLeft }} .{{ .Name }} {{ $.TmplDelimRight }}</td>{{ end }}
+    </tr>
+    {{ .TmplDelimLeft }} end {{ .TmplDelimRight }}
+  </tbody>
+</table>
+
+<h2>Create {{ .Name }}</h2>
+<form method="POST" action="{{ .ListPath }}">
+  <input type="hidden" name="anti_csrf_token" value="{{ .TmplDelimLeft }} anticsrftoken . {{ .TmplDelimRight }}">{{ range .Fields }}
+  <p>
+    <label>{{ .Name }}</label>
+    {{ if eq .InputType "textarea" }}<textarea name="{{ snakecase .Name }}"></textarea>{{ else }}<input type="{{ .InputType }}" name="{{ snakecase .Name }}"{{ if eq .InputType "checkbox" }} value="true"{{ end }}>{{ end }}
+  </p>{{ end }}
+  <button type="submit">Create</button>
+</form>
+{{ .TmplDelimLeft }} end {{ .TmplDelimRight }}
+`
+
+const resourceGetViewTemplate = `{{ .TmplDelimLeft }} define "title" {{ .TmplDelimRight }}{{ .Name }}{{ .TmplDelimLeft }} end {{ .TmplDelimRight }}
+
+{{ .TmplDelimLeft }} define "body" {{ .TmplDelimRight }}
+<h1>{{ .Name }} #{{ .TmplDelimLeft }} .{{ .Name }}.ID {{ .TmplDelimRight }}</h1>
+<dl>{{ range .Fields }}
+  <dt>{{ .Name }}</dt>
+  <dd>{{ $.TmplDelimLeft }} .{{ $.Name }}.{{ .Name }} {{ $.TmplDelimRight }}</dd>{{ end }}
+</dl>
+<a href="{{ .ListPath }}">Back to {{ .Name }} List</a>
+{{ .TmplDelimLeft }} end {{ .TmplDelimRight }}
+`
+
+const resourceListPugTemplate = `block title
+  | {{ .Name }} List
+
+block body
+  h1 {{ .Name }} List
+  table
+    thead
+      tr
+        th ID{{ range .Fields }}
+        th {{ .Name }}{{ end }}
+    tbody
+      each $v in .{{ .Name }}List
+        tr
+          td
+            a(href="{{ .ListPath }}/{{ .TmplDelimLeft }} $v.ID {{ .TmplDelimRight }}") #{$v.ID}{{ range .Fields }}
+          td #{$v.{{ .Name }}}{{ end }}
+
+  h2 Create {{ .Name }}
+  form(method="POST", action="{{ .ListPath }}")
+    input(type="hidden", name="anti_csrf_token", value="{{ .TmplDelimLeft }} anticsrftoken . {{ .TmplDelimRight }}"){{ range .Fields }}
+    p
+      label {{ .Name }}
+      {{ if eq .InputType "textarea" }}textarea(name="{{ snakecase .Name }}"){{ else }}input(type="{{ .InputType }}", name="{{ snakecase .Name }}"{{ if eq .InputType "checkbox" }}, value="true"{{ end }}){{ end }}{{ end }}
+    button(type="submit") Create
+`
+
+const resourceGetPugTemplate = `block title
+  | {{ .Name }}
+
+block body
+  h1 {{ .Name }} ##{.{{ .Name }}.ID}
+  dl{{ range .Fields }}
+    dt {{ .Name }}
+    dd #{.{{ $.Name }}.{{ .Name }}}{{ end }}
+  a(href="{{ .ListPath }}") Back to {{ .Name }} List
+`
+
+const codeMiddlewareTemplate = `package middleware
+
+import (
+	"aahframe.work"
+)
+
+// {{ .Name }} is the application middleware, it is registered in
+// 'app/init.go'. Doc: https://docs.aahframework.org/middleware.html
+func {{ .Name }}(ctx *aah.Context, m *aah.Middleware) {
+	// TODO: Before the next middleware in the chain
+
+	m.Next(ctx)
+
+	// TODO: After the next middleware in the chain
+}
+`
+
+const codeEventHandlerTemplate = `
+// {{ .Name }} method is the '{{ .Event }}' event handler.
+// Doc: https://docs.aahframework.org/server-extension.html
+func {{ .Name }}(e *aah.Event) {
+	{{ if .HTTPRequest -}}
+	ctx := e.Data.(*aah.Context)
+
+	// TODO: Implement your logic here
+	_ = ctx
+	{{- else -}}
+	// TODO: Implement your logic here
+	aah.App().Log().Infof("Event: %s", e.Name)
+	{{- end }}
+}
+`
+
+const codeAuthenticatorTemplate = `package security
+
+import (
+	"aahframe.work/config"
+	"aahframe.work/security/authc"
+)
+
+var _ authc.Authenticator = (*{{ .Name }})(nil)
+
+// {{ .Name }} struct implements 'authc.Authenticator' for the auth
+// scheme '{{ .Scheme }}'.
+type {{ .Name }} struct {
+}
+
+// Init method initializes the {{ .Name }}, this method gets called
+// during server start up.
+func (a *{{ .Name }}) Init(appCfg *config.Config) error {
+	// NOTE: Init is called on application startup
+	return nil
+}
+
+// GetAuthenticationInfo method is called by aah to obtain the authentication
+// information of the subject for the given auth token.
+func (a *{{ .Name }}) GetAuthenticationInfo(authcToken *authc.AuthenticationToken) (*authc.AuthenticationInfo, error) {
+	// TODO: Load the subject details from your data store using
+	// 'authcToken.Identity' and populate the authentication info.
+	return nil, authc.ErrSubjectNotExists
+}
+`
+
+const codePrincipalTemplate = `package security
+
+import (
+	"aahframe.work/config"
+	"aahframe.work/essentials"
+	"aahframe.work/security/authc"
+)
+
+var _ authc.PrincipalProvider = (*{{ .Name }})(nil)
+
+// {{ .Name }} struct implements 'authc.PrincipalProvider' for the auth
+// scheme '{{ .Scheme }}'.
+type {{ .Name }} struct {
+}
+
+// Init method initializes the {{ .Name }}, this method gets called
+// during server start up.
+func (p *{{ .Name }}) Init(appCfg *config.Config) error {
+	// NOTE: Init is called on application startup
+	return nil
+}
+
+// Principal method is called by aah to obtain the principals of the subject.
+func (p *{{ .Name }}) Principal(keyName string, v ess.Valuer) ([]*authc.Principal, error) {
+	// TODO: Load the principals of the subject from your data store.
+	return make([]*authc.Principal, 0), nil
+}
+`
+
+const codeAuthorizerTemplate = `package security
+
+import (
+	"aahframe.work/config"
+	"aahframe.work/security/authc"
+	"aahframe.work/security/authz"
+)
+
+var _ authz.Authorizer = (*{{ .Name }})(nil)
+
+// {{ .Name }} struct implements 'authz.Authorizer' for the auth
+// scheme '{{ .Scheme }}'.
+type {{ .Name }} struct {
+}
+
+// Init method initializes the {{ .Name }}, this method gets called
+// during server start up.
+func (a *{{ .Name }}) Init(appCfg *config.Config) error {
+	// NOTE: Init is called on application startup
+	return nil
+}
+
+// GetAuthorizationInfo method is called by aah to obtain the roles and
+// permissions of the authenticated subject.
+func (a *{{ .Name }}) GetAuthorizationInfo(authcInfo *authc.AuthenticationInfo) *authz.AuthorizationInfo {
+	// TODO: Load the roles and permissions of the subject from your data store.
+	authzInfo := authz.NewAuthorizationInfo()
+	return authzInfo
+}
+`
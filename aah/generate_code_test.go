@@ -0,0 +1,301 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	cliLog = initCLILogger(nil)
+	os.Exit(m.Run())
+}
+
+const testRoutesConf = `domains {
+  # localhost { routes { } }
+  localhost {
+    name = "app routes"
+    host = "localhost"
+    routes {
+      index {
+        path = "/"
+        controller = "AppController"
+      }
+      list_users {
+        path = "/users"
+      }
+    }
+  }
+  api { routes {
+  } }
+}
+`
+
+func TestConfBlock(t *testing.T) {
+	testcases := []struct {
+		label string
+		src   string
+		key   string
+		block string
+		found bool
+	}{
+		{label: "top level", src: "a { k = 1 }\nb { k = 2 }", key: "b", block: "{ k = 2 }", found: true},
+		{label: "first block", src: "x = 1\na { b { } }\nc { }", key: "", block: "{ b { } }", found: true},
+		{label: "nested block is not direct", src: "x { a { } }", key: "a"},
+		{label: "brace in comment", src: "# a { \n// a }\na { k = 1 }", key: "a", block: "{ k = 1 }", found: true},
+		{label: "brace in quoted value", src: "s = \"{ a { }\"\na { k = \"}\" }", key: "a", block: "{ k = \"}\" }", found: true},
+		{label: "key with dash and dot", src: "api-v1.x { }", key: "api-v1.x", block: "{ }", found: true},
+		{label: "not found", src: "a { }", key: "b"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.label, func(t *testing.T) {
+			src := []byte(tc.src)
+			open, end, found := confBlock(src, 0, len(src), tc.key)
+			if found != tc.found {
+				t.Fatalf("expected found %v, got %v", tc.found, found)
+			}
+			if found && string(src[open:end+1]) != tc.block {
+				t.Errorf("expected block %q, got %q", tc.block, src[open:end+1])
+			}
+		})
+	}
+}
+
+func TestConfKeyValue(t *testing.T) {
+	testcases := []struct {
+		label string
+		src   string
+		key   string
+		value string
+		found bool
+	}{
+		{label: "unquoted", src: "a = 1", key: "a", value: "1", found: true},
+		{label: "trailing comment", src: "a = 1 # comment\n", key: "a", value: "1", found: true},
+		{label: "colon separator", src: "a: true\n", key: "a", value: "true", found: true},
+		{label: "quoted with escape", src: "name = \"a \\\" b\" # c", key: "name", value: "\"a \\\" b\"", found: true},
+		{label: "key prefix", src: "name_x = 1\nname = 2\n", key: "name", value: "2", found: true},
+		{label: "key suffix", src: "rename = 1\n", key: "name"},
+		{label: "nested key", src: "x { name = 2 }", key: "name"},
+		{label: "commented key", src: "# name = 2\n", key: "name"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.label, func(t *testing.T) {
+			src := []byte(tc.src)
+			start, end, found := confKeyValue(src, 0, len(src), tc.key)
+			if found != tc.found {
+				t.Fatalf("expected found %v, got %v", tc.found, found)
+			}
+			if found && string(src[start:end]) != tc.value {
+				t.Errorf("expected value %q, got %q", tc.value, src[start:end])
+			}
+		})
+	}
+}
+
+func TestSetConfKey(t *testing.T) {
+	securityConf := "security {\n  # session { }\n  session {\n    mode = \"stateless\" # comment\n    ttl = 30m\n  }\n}\n"
+	testcases := []struct {
+		label     string
+		src       string
+		blockPath []string
+		key       string
+		value     string
+		expected  string
+	}{
+		{
+			label:     "replace quoted value, comment retained",
+			src:       securityConf,
+			blockPath: []string{"security", "session"},
+			key:       "mode",
+			value:     "stateful",
+			expected:  "security {\n  # session { }\n  session {\n    mode = \"stateful\" # comment\n    ttl = 30m\n  }\n}\n",
+		},
+		{
+			label:     "replace unquoted value",
+			src:       securityConf,
+			blockPath: []string{"security", "session"},
+			key:       "ttl",
+			value:     "1h",
+			expected:  "security {\n  # session { }\n  session {\n    mode = \"stateless\" # comment\n    ttl = \"1h\"\n  }\n}\n",
+		},
+		{
+			label:     "add key at end of block",
+			src:       securityConf,
+			blockPath: []string{"security", "session"},
+			key:       "cookie_name",
+			value:     "x",
+			expected:  "security {\n  # session { }\n  session {\n    mode = \"stateless\" # comment\n    ttl = 30m\n    cookie_name = \"x\"\n  }\n}\n",
+		},
+		{
+			label:     "create missing nested blocks",
+			src:       securityConf,
+			blockPath: []string{"security", "auth_schemes", "jwt"},
+			key:       "realm",
+			value:     "x",
+			expected:  "security {\n  # session { }\n  session {\n    mode = \"stateless\" # comment\n    ttl = 30m\n  }\n  auth_schemes {\n    jwt {\n      realm = \"x\"\n    }\n  }\n}\n",
+		},
+		{
+			label:     "create missing top level block",
+			src:       "a {\n}\n",
+			blockPath: []string{"db"},
+			key:       "driver",
+			value:     "x",
+			expected:  "a {\n}\n\ndb {\n  driver = \"x\"\n}\n",
+		},
+		{
+			label:     "empty source",
+			src:       "",
+			blockPath: []string{"a", "b"},
+			key:       "k",
+			value:     "v",
+			expected:  "\na {\n  b {\n    k = \"v\"\n  }\n}\n",
+		},
+		{
+			label:     "empty block",
+			src:       "a {\n}\n",
+			blockPath: []string{"a"},
+			key:       "k",
+			value:     "v",
+			expected:  "a {\n  k = \"v\"\n}\n",
+		},
+		{
+			label:    "top level key",
+			src:      "mode=1\n",
+			key:      "mode",
+			value:    "v",
+			expected: "mode=\"v\"\n",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.label, func(t *testing.T) {
+			result, err := setConfKey([]byte(tc.src), tc.blockPath, tc.key, tc.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(result) != tc.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestAddRoutes(t *testing.T) {
+	listUsers := &routeEntry{Key: "list_users", Path: "/users", Method: "GET", Controller: "UserController", Action: "List"}
+	token := &routeEntry{Key: "token", Path: "/v1/token", Method: "POST", Controller: "v1/TokenController", Action: "Token", Auth: "anonymous"}
+
+	testcases := []struct {
+		label    string
+		src      string
+		domain   string
+		routes   []*routeEntry
+		added    int
+		expected string
+		err      string
+	}{
+		{
+			label:    "existing route is skipped",
+			src:      testRoutesConf,
+			routes:   []*routeEntry{listUsers},
+			expected: testRoutesConf,
+		},
+		{
+			label:  "first domain, commented block is skipped",
+			src:    testRoutesConf,
+			routes: []*routeEntry{listUsers, token},
+			added:  1,
+			expected: `domains {
+  # localhost { routes { } }
+  localhost {
+    name = "app routes"
+    host = "localhost"
+    routes {
+      index {
+        path = "/"
+        controller = "AppController"
+      }
+      list_users {
+        path = "/users"
+      }
+
+      token {
+        path = "/v1/token"
+        method = "POST"
+        controller = "v1/TokenController"
+        action = "Token"
+        auth = "anonymous"
+      }
+    }
+  }
+  api { routes {
+  } }
+}
+`,
+		},
+		{
+			label:  "domain by key",
+			src:    testRoutesConf,
+			domain: "api",
+			routes: []*routeEntry{listUsers},
+			added:  1,
+			expected: `domains {
+  # localhost { routes { } }
+  localhost {
+    name = "app routes"
+    host = "localhost"
+    routes {
+      index {
+        path = "/"
+        controller = "AppController"
+      }
+      list_users {
+        path = "/users"
+      }
+    }
+  }
+  api { routes {
+
+    list_users {
+      path = "/users"
+      method = "GET"
+      controller = "UserController"
+      action = "List"
+    }
+  } }
+}
+`,
+		},
+		{label: "domain not found", src: testRoutesConf, domain: "nope", routes: []*routeEntry{listUsers},
+			err: "routes.conf: domain 'nope' not found"},
+		{label: "domains block not found", src: "x { }", routes: []*routeEntry{listUsers},
+			err: "routes.conf: 'domains' block not found"},
+		{label: "routes block not found", src: "domains { d { } }", routes: []*routeEntry{listUsers},
+			err: "routes.conf: 'routes' block not found in the domain"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.label, func(t *testing.T) {
+			result, added, err := addRoutes([]byte(tc.src), tc.domain, tc.routes)
+			if len(tc.err) > 0 {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("expected error %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(added) != tc.added {
+				t.Errorf("expected %d routes added, got %d", tc.added, len(added))
+			}
+			if string(result) != tc.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tc.expected, result)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ module aahframe.work/cli/aah
 
 require (
 	aahframe.work v0.12.5
+	github.com/andybalholm/brotli v1.1.1
 	github.com/radovskyb/watcher v1.0.7
 	github.com/stretchr/testify v1.3.0 // indirect
 	golang.org/x/sys v0.0.0-20220731174439-a90be440212d // indirect
@@ -9,4 +10,4 @@ require (
 	gopkg.in/go-playground/assert.v1 v1.2.1 // indirect
 )
 
-go 1.11
+go 1.13
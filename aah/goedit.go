@@ -0,0 +1,254 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"aahframe.work/essentials"
+)
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Go source editing
+//___________________________________
+
+// goSrcFile struct holds the parsed Go source file for editing. Positions of
+// the edits are found via Go AST and applied on the source, so the existing
+// formatting and comments are preserved. Result is parsed and formatted
+// before it is written.
+type goSrcFile struct {
+	name  string
+	src   []byte
+	fset  *token.FileSet
+	f     *ast.File
+	edits []srcEdit
+}
+
+// srcEdit struct replaces the source between offset and end with text, it's
+// an insert if end is same as offset.
+type srcEdit struct {
+	offset int
+	end    int
+	text   string
+}
+
+func parseGoSrcFile(filename string) (*goSrcFile, error) {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	return &goSrcFile{name: filename, src: src, fset: fset, f: f}, nil
+}
+
+func (g *goSrcFile) offset(p token.Pos) int {
+	return g.fset.Position(p).Offset
+}
+
+func (g *goSrcFile) text(n ast.Node) string {
+	return string(g.src[g.offset(n.Pos()):g.offset(n.End())])
+}
+
+func (g *goSrcFile) insert(p token.Pos, text string) {
+	offset := g.offset(p)
+	g.edits = append(g.edits, srcEdit{offset: offset, end: offset, text: text})
+}
+
+// initFunc method returns the 'func init()' declaration.
+func (g *goSrcFile) initFunc() (*ast.FuncDecl, error) {
+	for _, d := range g.f.Decls {
+		if fn, ok := d.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == "init" {
+			return fn, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: func init() not found", g.name)
+}
+
+// hasFunc method returns true if top level func exists with given name.
+func (g *goSrcFile) hasFunc(name string) bool {
+	for _, d := range g.f.Decls {
+		if fn, ok := d.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// addImport method adds the import path if not imported already.
+func (g *goSrcFile) addImport(importPath string) {
+	if ess.IsStrEmpty(importPath) {
+		return
+	}
+	for _, imp := range g.f.Imports {
+		if p, err := strconv.Unquote(imp.Path.Value); err == nil && p == importPath {
+			return
+		}
+	}
+
+	quoted := strconv.Quote(importPath)
+	var impDecl *ast.GenDecl
+	for _, d := range g.f.Decls {
+		if gd, ok := d.(*ast.GenDecl); ok && gd.Tok == token.IMPORT {
+			impDecl = gd
+			break
+		}
+	}
+	switch {
+	case impDecl == nil:
+		g.insert(g.f.Name.End(), "\n\nimport "+quoted)
+	case impDecl.Lparen.IsValid():
+		g.insert(impDecl.Rparen, "\n\t"+quoted+"\n")
+	default:
+		g.insert(impDecl.End(), "\nimport "+quoted)
+	}
+}
+
+// addInitStmts method adds the statements at the end of 'func init()' body.
+// Statements and comments exists already are skipped. If 'func init()' does
+// not exist, it's appended at the end of the file.
+func (g *goSrcFile) addInitStmts(stmts ...string) error {
+	fn, err := g.initFunc()
+	if err != nil {
+		g.appendDecl("func init() {\n\t" + strings.Join(stmts, "\n\t") + "\n}")
+		return nil
+	}
+
+	existing := make(map[string]bool)
+	for _, s := range fn.Body.List {
+		existing[normalizeGoSrc(g.text(s))] = true
+	}
+	bodyText := g.text(fn.Body)
+
+	var buf strings.Builder
+	for _, stmt := range stmts {
+		if strings.HasPrefix(stmt, "//") {
+			if !strings.Contains(bodyText, stmt) {
+				buf.WriteString("\n\t" + stmt)
+			}
+			continue
+		}
+		if !existing[normalizeGoSrc(stmt)] {
+			buf.WriteString("\n\t" + stmt)
+		}
+	}
+	if buf.Len() > 0 {
+		buf.WriteString("\n")
+		start, end := g.offset(fn.Body.Lbrace)+1, g.offset(fn.Body.Rbrace)
+		if len(strings.TrimSpace(string(g.src[start:end]))) == 0 {
+			// empty body, blank lines are replaced
+			g.edits = append(g.edits, srcEdit{offset: start, end: end, text: buf.String()})
+		} else {
+			g.insert(fn.Body.Rbrace, buf.String())
+		}
+	}
+	return nil
+}
+
+// addCallArg method adds the argument into the first call of method 'sel'
+// found in 'func init()', e.g. 'Middlewares'. Argument is added before the
+// 'before' argument if exists otherwise at the end. It returns false if
+// argument exists already.
+func (g *goSrcFile) addCallArg(sel, arg, before string) (bool, error) {
+	fn, err := g.initFunc()
+	if err != nil {
+		return false, err
+	}
+
+	var call *ast.CallExpr
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if call != nil {
+			return false
+		}
+		if ce, ok := n.(*ast.CallExpr); ok {
+			if se, ok := ce.Fun.(*ast.SelectorExpr); ok && se.Sel.Name == sel {
+				call = ce
+				return false
+			}
+		}
+		return true
+	})
+	if call == nil {
+		return false, fmt.Errorf("%s: call '%s(...)' not found in func init()", g.name, sel)
+	}
+
+	for _, a := range call.Args {
+		if normalizeGoSrc(g.text(a)) == normalizeGoSrc(arg) {
+			return false, nil
+		}
+	}
+	for _, a := range call.Args {
+		if normalizeGoSrc(g.text(a)) == normalizeGoSrc(before) {
+			g.insert(a.Pos(), arg+",\n")
+			return true, nil
+		}
+	}
+
+	// at the end, take care of trailing comma
+	prefix := "\n"
+	if len(call.Args) > 0 {
+		between := strings.TrimSpace(string(g.src[g.offset(call.Args[len(call.Args)-1].End()):g.offset(call.Rparen)]))
+		if !strings.HasPrefix(between, ",") {
+			prefix = ",\n"
+		}
+	}
+	g.insert(call.Rparen, prefix+arg+",\n")
+	return true, nil
+}
+
+// appendDecl method appends the declaration at the end of the file.
+func (g *goSrcFile) appendDecl(decl string) {
+	g.edits = append(g.edits, srcEdit{offset: len(g.src), end: len(g.src), text: "\n" + strings.TrimSpace(decl) + "\n"})
+}
+
+// save method applies the edits, formats and writes the file.
+func (g *goSrcFile) save() error {
+	if len(g.edits) == 0 {
+		return nil
+	}
+
+	// apply edits from bottom to top, so offsets stays valid
+	src := append([]byte(nil), g.src...)
+	sort.SliceStable(g.edits, func(i, j int) bool { return g.edits[i].offset > g.edits[j].offset })
+	for _, e := range g.edits {
+		src = append(src[:e.offset], append([]byte(e.text), src[e.end:]...)...)
+	}
+
+	src, err := format.Source(src)
+	if err != nil {
+		return fmt.Errorf("%s: format source error: %s", g.name, err)
+	}
+	return ioutil.WriteFile(g.name, src, permRWRWRW)
+}
+
+func normalizeGoSrc(s string) string {
+	return strings.Join(strings.Fields(s), "")
+}
+
+// addInitFuncStmts method adds the import path and statements into the 'init'
+// func of given Go source file. Statements and import already exists are
+// skipped, so it is safe to call again.
+func addInitFuncStmts(filename, importPath string, stmts ...string) error {
+	g, err := parseGoSrcFile(filename)
+	if err != nil {
+		return err
+	}
+	if err = g.addInitStmts(stmts...); err != nil {
+		return err
+	}
+	g.addImport(importPath)
+	return g.save()
+}
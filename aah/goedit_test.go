@@ -0,0 +1,131 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files of testdata")
+
+// TestGoSrcFileEdits applies the edits on 'testdata/goedit/<name>.input' and
+// compares the result with '<name>.golden'. Edits are applied again on the
+// result to verify repeated runs does not change the file.
+func TestGoSrcFileEdits(t *testing.T) {
+	dbStmts := []string{
+		"// Database connection lifecycle, refer to 'app/db/db.go'",
+		"aah.App().OnStart(db.Connect)",
+		"aah.App().OnPostShutdown(db.Close)",
+	}
+	addDB := func(g *goSrcFile) error {
+		if err := g.addInitStmts(dbStmts...); err != nil {
+			return err
+		}
+		g.addImport("example.com/app/app/db")
+		return nil
+	}
+	addMiddleware := func(g *goSrcFile) error {
+		g.addImport("example.com/app/app/middleware")
+		_, err := g.addCallArg("Middlewares", "middleware.RequestID", "aah.ActionMiddleware")
+		return err
+	}
+
+	testcases := []struct {
+		name string
+		edit func(g *goSrcFile) error
+	}{
+		{name: "existing_imports", edit: addDB},
+		{name: "empty_init", edit: addDB},
+		{name: "missing_init", edit: addDB},
+		{name: "no_imports", edit: addDB},
+		{name: "call_arg_before", edit: addMiddleware},
+		{name: "call_arg_end", edit: addMiddleware},
+		{name: "append_decl", edit: func(g *goSrcFile) error {
+			if !g.hasFunc("onStart") {
+				g.appendDecl("func onStart(_ *aah.Event) {\n}")
+			}
+			return g.addInitStmts("app.OnStart(onStart)")
+		}},
+	}
+
+	tmpDir, err := ioutil.TempDir("", "goedit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			input, err := ioutil.ReadFile(filepath.Join("testdata", "goedit", tc.name+".input"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			filename := filepath.Join(tmpDir, tc.name+".go")
+			if err = ioutil.WriteFile(filename, input, permRWRWRW); err != nil {
+				t.Fatal(err)
+			}
+
+			result := applyGoSrcEdit(t, filename, tc.edit)
+			goldenFile := filepath.Join("testdata", "goedit", tc.name+".golden")
+			if *updateGolden {
+				if err = ioutil.WriteFile(goldenFile, result, permRWRWRW); err != nil {
+					t.Fatal(err)
+				}
+			}
+			golden, err := ioutil.ReadFile(goldenFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(result, golden) {
+				t.Errorf("result does not match '%s':\n%s", goldenFile, result)
+			}
+
+			if again := applyGoSrcEdit(t, filename, tc.edit); !bytes.Equal(again, result) {
+				t.Errorf("repeated edit changed the file:\n%s", again)
+			}
+		})
+	}
+}
+
+func TestGoSrcFileCallNotFound(t *testing.T) {
+	tmpFile, err := ioutil.TempFile("", "goedit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Remove(tmpFile.Name()) }()
+	_, _ = tmpFile.WriteString("package main\n\nfunc init() {}\n")
+	_ = tmpFile.Close()
+
+	g, err := parseGoSrcFile(tmpFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = g.addCallArg("Middlewares", "middleware.RequestID", ""); err == nil {
+		t.Error("expected error for missing 'Middlewares(...)' call")
+	}
+}
+
+func applyGoSrcEdit(t *testing.T, filename string, edit func(g *goSrcFile) error) []byte {
+	g, err := parseGoSrcFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = edit(g); err != nil {
+		t.Fatal(err)
+	}
+	if err = g.save(); err != nil {
+		t.Fatal(err)
+	}
+	result, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
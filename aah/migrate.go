@@ -195,12 +195,14 @@ func migrateCodeAction(c *console.Context) error {
 		if ess.IsFileExists("views") {
 			data.Type = typeWeb
 		}
-		processFile(appBaseDir, file{
+		if err = processFile(appBaseDir, file{
 			src: filepath.Join(appTmplBaseDir, "go.mod.atmpl"),
 			dst: filepath.Join(appBaseDir, "go.mod.atmpl"),
 		}, map[string]interface{}{
 			"App": data,
-		})
+		}); err != nil {
+			logFatal(err)
+		}
 	}
 
 	cliLog.Infof("Code migration successful for '%s' [%s]\n", app.Name(), app.ImportPath())
@@ -357,9 +359,11 @@ func checkAndGenerateInitgoFile(importPath, baseDir string, appCfg *config.Confi
 			},
 		}
 
-		processFile(baseDir, file{
+		if err := processFile(baseDir, file{
 			src: filepath.Join(appTmplBaseDir, "app", "init.go.atmpl"),
 			dst: filepath.Join(baseDir, "app", "init.go"),
-		}, data)
+		}, data); err != nil {
+			logFatal(err)
+		}
 	}
 }
@@ -7,15 +7,21 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"go/format"
-	"io"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
 
+	"aahframe.work/config"
 	"aahframe.work/console"
 	"aahframe.work/essentials"
 )
@@ -24,31 +30,225 @@ var (
 	newCmd = console.Command{
 		Name:    "new",
 		Aliases: []string{"n"},
-		Usage:   "Creates new aah 'web', 'api' or 'websocket' application (interactive)",
+		Usage:   "Creates new aah 'web', 'api' or 'websocket' application (interactive or via flags)",
 		Description: `Command 'new' is an interactive program to assist you to quick start aah application.
 
 	Just provide your inputs based on your use case to generate base structure to kickstart 
 	your development.
 
-	Application templates are kept at '$HOME/.aah/app-templates'.
+	Every prompt could be answered upfront via flags or answers file, in that case 'new'
+	does not prompt for it. With answers file or flag '--non-interactive', unanswered
+	prompts takes its default value and command runs non-interactively.
+
+	Application templates are kept at '$HOME/.aah/app-templates'. Use flags '--template-dir',
+	'--template-ref' and '--offline' (or env variables 'AAH_TEMPLATES', 'AAH_TEMPLATES_REF'
+	and 'AAH_OFFLINE=true') for local, pinned or air-gapped usage.
+
+	Third-party app templates could be registered by name and used via flag '--template'.
+	Template carries manifest file 'aah.template' describing its prompts, file rules and
+	post create hooks. Registered templates are kept at '$HOME/.aah/app-templates.json'.
+
+	Example:
+		aah new
+		aah new --register-template acme-api --template-dir https://github.com/acme/aah-api-template.git
+		aah new --template acme-api --var team=payments
+		aah new --non-interactive --import-path github.com/user/app --dir /path/to/dir --type api
+		aah new --answers /path/to/answers.json
+		aah new --answers /path/to/answers.json --dry-run
+		aah new --offline --template-ref v0.12.0
+		aah new --template-dir /path/to/app-templates.tar.gz
 
 	Go to https://docs.aahframework.org to learn more and customize your aah application.`,
+		Flags: []console.Flag{
+			console.StringFlag{
+				Name:  "template, t",
+				Usage: "Registered app template name, default is '" + defaultAppTmplName + "'",
+			},
+			console.StringFlag{
+				Name:  "register-template",
+				Usage: "Registers app template `NAME` with location given via '--template-dir' and optional '--template-ref'",
+			},
+			console.StringSliceFlag{
+				Name:  "var",
+				Usage: "Answer for app template specific prompt in the format 'key=value', could be repeated",
+			},
+			console.StringFlag{
+				Name:  "template-dir",
+				Usage: "Local app templates directory or tarball (.tar, .tar.gz, .tgz) instead of aah app-templates repo, or git repository URL with '--register-template' (remote tarball is not supported)",
+			},
+			console.StringFlag{
+				Name:  "template-ref",
+				Usage: "Branch, tag or commit of app templates repo to use, default is '" + templateBranchName + "'",
+			},
+			console.BoolFlag{
+				Name:  "offline",
+				Usage: "Uses cached app templates from '$HOME/.aah/app-templates' without network access",
+			},
+			console.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Prints the template variables and file plan with conflicts, no files are written",
+			},
+			console.BoolFlag{
+				Name:  "force, f",
+				Usage: "Overwrites the existing files in the application directory",
+			},
+			console.BoolFlag{
+				Name:  "non-interactive",
+				Usage: "Does not prompt, unanswered prompts takes its default value",
+			},
+			console.StringFlag{
+				Name:  "answers, a",
+				Usage: "Answers `FILE` ('.conf' or '.json') for the prompts, values given via flags takes precedence",
+			},
+			console.StringFlag{
+				Name:  "import-path",
+				Usage: "Application import path",
+			},
+			console.StringFlag{
+				Name:  "dir",
+				Usage: "Application location, directory gets created as '<dir>/<import-path-base>'",
+			},
+			console.StringFlag{
+				Name:  "type",
+				Usage: "Application type 'web', 'api' or 'websocket'",
+			},
+			console.StringFlag{
+				Name:  "view-engine",
+				Usage: "View engine for 'web' application",
+			},
+			console.StringFlag{
+				Name:  "auth-scheme",
+				Usage: "Auth scheme 'form', 'basic', 'oauth2' for 'web' application and 'basic', 'generic', 'jwt' for 'api' application",
+			},
+			console.StringFlag{
+				Name:  "oauth2-provider",
+				Usage: "OAuth2 provider 'github', 'google' or 'oidc'",
+			},
+			console.StringFlag{
+				Name:  "oauth2-client-id-env",
+				Usage: "Environment variable name for OAuth2 client id",
+			},
+			console.StringFlag{
+				Name:  "oauth2-client-secret-env",
+				Usage: "Environment variable name for OAuth2 client secret",
+			},
+			console.StringFlag{
+				Name:  "oauth2-callback-path",
+				Usage: "OAuth2 callback URL path",
+			},
+			console.StringFlag{
+				Name:  "oauth2-auth-url",
+				Usage: "OAuth2 authorization endpoint URL, required for 'oidc' provider",
+			},
+			console.StringFlag{
+				Name:  "oauth2-token-url",
+				Usage: "OAuth2 token endpoint URL, required for 'oidc' provider",
+			},
+			console.StringFlag{
+				Name:  "basic-auth-mode",
+				Usage: "Basic auth mode 'file-realm' or 'dynamic'",
+			},
+			console.StringFlag{
+				Name:  "password-hash",
+				Usage: "Password hash algorithm 'bcrypt', 'scrypt' or 'pbkdf2'",
+			},
+			console.StringFlag{
+				Name:  "session-store",
+				Usage: "Session store 'cookie' or 'file'",
+			},
+			console.StringFlag{
+				Name:  "database",
+				Usage: "Database layer 'sqlite', 'postgres', 'mysql' or 'none' for 'web' and 'api' application",
+			},
+			console.BoolFlag{
+				Name:  "cors",
+				Usage: "Enables CORS for the application",
+			},
+			console.StringFlag{
+				Name:  "sub-types",
+				Usage: "Comma separated sub types 'api', 'websocket' within 'web' application, use 'none' for no sub types",
+			},
+		},
 		Action: newAction,
 	}
 
 	reader = bufio.NewReader(os.Stdin)
 )
 
+// appAnswers struct holds the answers given upfront for 'aah new' prompts
+// via flags or answers file.
+type appAnswers struct {
+	ImportPath    string `json:"import_path"`
+	Dir           string `json:"dir"`
+	Type          string `json:"type"`
+	ViewEngine    string `json:"view_engine"`
+	AuthScheme    string `json:"auth_scheme"`
+	BasicAuthMode string `json:"basic_auth_mode"`
+	PasswordHash  string `json:"password_hash"`
+	SessionStore  string `json:"session_store"`
+	Database      string `json:"database"`
+
+	OAuth2Provider        string `json:"oauth2_provider"`
+	OAuth2ClientIDEnv     string `json:"oauth2_client_id_env"`
+	OAuth2ClientSecretEnv string `json:"oauth2_client_secret_env"`
+	OAuth2CallbackPath    string `json:"oauth2_callback_path"`
+	OAuth2AuthURL         string `json:"oauth2_auth_url"`
+	OAuth2TokenURL        string `json:"oauth2_token_url"`
+
+	CORS     *bool    `json:"cors"`
+	SubTypes []string `json:"sub_types"`
+
+	// Vars holds answers for app template specific prompts
+	Vars map[string]string `json:"vars"`
+
+	// interactive is false when answers file or flag '--non-interactive' is given
+	interactive bool
+}
+
 func newAction(c *console.Context) error {
 	cliLog = initCLILogger(nil)
-	fmt.Println("\nWelcome to interactive way to create your aah application, press ^C to exit :)")
-	fmt.Println()
-	fmt.Println("Based on your inputs, aah CLI generates the aah application structure for you.")
+	if dir := c.String("template-dir"); !ess.IsStrEmpty(dir) {
+		appTmplSrc.Dir = dir
+	}
+	if ref := c.String("template-ref"); !ess.IsStrEmpty(ref) {
+		appTmplSrc.Ref = ref
+	}
+	if c.Bool("offline") {
+		appTmplSrc.Offline = true
+	}
+
+	if name := c.String("register-template"); !ess.IsStrEmpty(name) {
+		if err := loadAppTmplRegistry().Register(name, c.String("template-dir"), c.String("template-ref")); err != nil {
+			logFatal(err)
+		}
+		cliLog.Infof("App template '%s' registered successfully, use it via 'aah new --template %s'\n", name, name)
+		return nil
+	}
+
+	ans, err := collectAnswers(c)
+	if err != nil {
+		logFatal(err)
+	}
+
+	tmplName := c.String("template")
+	appTmplBaseDir, manifest, err := resolveAppTmpl(tmplName)
+	if err != nil {
+		logFatal(err)
+	}
+	if manifest != nil {
+		manifest.ApplyAnswers(ans)
+	}
+
+	if ans.interactive {
+		fmt.Println("\nWelcome to interactive way to create your aah application, press ^C to exit :)")
+		fmt.Println()
+		fmt.Println("Based on your inputs, aah CLI generates the aah application structure for you.")
+	}
 
 	// Collect inputs for aah app creation
-	importPath := collectImportPath(reader)
-	appDir := collectAppDir(reader, importPath)
-	appType := collectAppType(reader)
+	importPath := collectImportPath(reader, ans)
+	appDir := collectAppDir(reader, ans, importPath)
+	appType := collectAppType(reader, ans)
 
 	// Depends on application type choice, collect subsequent inputs
 	app := &appTmplData{
@@ -57,13 +257,20 @@ func newAction(c *console.Context) error {
 		Type:           appType,
 		TmplDelimLeft:  "{{",
 		TmplDelimRight: "}}",
+		Template:       tmplName,
+		tmplBaseDir:    appTmplBaseDir,
+		manifest:       manifest,
 	}
 
 	switch appType {
 	case typeWeb:
-		collectInputsForWebApp(c, app)
+		collectInputsForWebApp(ans, app)
 	case typeAPI:
-		collectInputsForAPIApp(c, app)
+		collectInputsForAPIApp(ans, app)
+	}
+
+	if manifest != nil {
+		app.Vars = manifest.CollectVars(ans)
 	}
 
 	// Process it
@@ -76,18 +283,61 @@ func newAction(c *console.Context) error {
 		app.BasicAuthFileRealmPath = "/path/to/basic-realm.conf"
 	}
 
-	if err := createAahApp(app.BaseDir, map[string]interface{}{
+	files := appTmplFilePlan(app)
+	if c.Bool("dry-run") {
+		printAppTmplPlan(app, files)
+		return nil
+	}
+
+	if conflicts := appTmplConflicts(files); len(conflicts) > 0 {
+		if !c.Bool("force") {
+			logFatalf("Following files already exists at '%s', use '--force' to overwrite:\n\t%s",
+				app.BaseDir, strings.Join(conflicts, "\n\t"))
+		}
+		cliLog.Warnf("Overwriting %d existing file(s) at '%s'", len(conflicts), app.BaseDir)
+	}
+
+	data := map[string]interface{}{
 		"App": app,
-	}); err != nil {
+	}
+	if err := createAahApp(app, files, data); err != nil {
 		logFatal(err)
 	}
 
+	if manifest != nil {
+		if err := manifest.PostCreateHooks(app.BaseDir, data); err != nil {
+			logError(err)
+		}
+	}
+
 	fmt.Printf("\nYour aah %s application was created successfully at '%s'\n", app.Type, app.BaseDir)
 	fmt.Println("You shall run your application via the command 'aah run' from application base directory.")
 	fmt.Println("\nGo to https://docs.aahframework.org to learn more and customize your aah application.")
 
 	_ = aahInventory.AddProject(app.ImportPath, app.BaseDir)
 
+	if app.IsOAuth2() {
+		fmt.Println("\nNext step:")
+		fmt.Printf("\tRegister OAuth2 application with '%s' using callback URL path '%s'\n", app.OAuth2Provider, app.OAuth2CallbackPath)
+		fmt.Printf("\tSet environment variables '%s' and '%s' with client credentials\n", app.OAuth2ClientIDEnv, app.OAuth2ClientSecretEnv)
+		fmt.Println("\tImplement 'app/security/oauth2_principal_provider.go', refer to 'https://docs.aahframework.org/auth-schemes/oauth2.html'")
+	}
+
+	if app.IsJWT() && manifest == nil {
+		fmt.Println("\nNext step:")
+		fmt.Printf("\tToken route '%s %s' is added into 'config/routes.conf', set 'default_auth = \"jwt_auth\"' for the domain\n",
+			jwtTokenRoute.Method, jwtTokenRoute.Path)
+		fmt.Println("\tSet environment variable 'JWT_SIGNING_KEY' in production, it overrides the generated signing key")
+		fmt.Println("\tReplace sample credentials check in 'app/security/jwt_users.go' with your user store")
+	}
+
+	if app.IsDatabaseEnabled() && manifest == nil {
+		fmt.Println("\nNext step:")
+		fmt.Printf("\tDatabase '%s' is configured at 'config/db.conf', update the 'db.dsn' as needed\n", app.Database)
+		fmt.Printf("\tFetch the database driver via 'go get %s'\n", app.DBDriverImport())
+		fmt.Println("\tAdd migrations into 'db/migrations', they are applied in order on application start")
+	}
+
 	if app.BasicAuthMode == basicFileRealm {
 		fmt.Println("\nNext step:")
 		fmt.Println("\tSample Basic Auth file-realm have been created at", app.BasicAuthFileRealmPath)
@@ -97,6 +347,120 @@ func newAction(c *console.Context) error {
 	return nil
 }
 
+// collectAnswers method reads the answers file if given and then applies
+// the values given via flags on top of it.
+func collectAnswers(c *console.Context) (*appAnswers, error) {
+	ans := &appAnswers{interactive: !c.Bool("non-interactive")}
+	if answersFile := c.String("answers"); !ess.IsStrEmpty(answersFile) {
+		var err error
+		if ans, err = loadAnswersFile(answersFile); err != nil {
+			return nil, err
+		}
+	}
+
+	for flagName, v := range map[string]*string{
+		"import-path":              &ans.ImportPath,
+		"dir":                      &ans.Dir,
+		"type":                     &ans.Type,
+		"view-engine":              &ans.ViewEngine,
+		"auth-scheme":              &ans.AuthScheme,
+		"basic-auth-mode":          &ans.BasicAuthMode,
+		"password-hash":            &ans.PasswordHash,
+		"session-store":            &ans.SessionStore,
+		"database":                 &ans.Database,
+		"oauth2-provider":          &ans.OAuth2Provider,
+		"oauth2-client-id-env":     &ans.OAuth2ClientIDEnv,
+		"oauth2-client-secret-env": &ans.OAuth2ClientSecretEnv,
+		"oauth2-callback-path":     &ans.OAuth2CallbackPath,
+		"oauth2-auth-url":          &ans.OAuth2AuthURL,
+		"oauth2-token-url":         &ans.OAuth2TokenURL,
+	} {
+		if s := strings.TrimSpace(c.String(flagName)); !ess.IsStrEmpty(s) {
+			*v = s
+		}
+	}
+
+	if ans.Vars == nil {
+		ans.Vars = make(map[string]string)
+	}
+	for _, v := range c.StringSlice("var") {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("Invalid template variable '%s', use format 'key=value'", v)
+		}
+		ans.Vars[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+
+	if c.IsSet("cors") {
+		cors := c.Bool("cors")
+		ans.CORS = &cors
+	}
+
+	if c.IsSet("sub-types") {
+		ans.SubTypes = make([]string, 0)
+		ans.SubTypes = append(ans.SubTypes, strings.Split(c.String("sub-types"), ",")...)
+	}
+
+	return ans, nil
+}
+
+func loadAnswersFile(answersFile string) (*appAnswers, error) {
+	ans := &appAnswers{}
+	switch filepath.Ext(answersFile) {
+	case ".json":
+		b, err := ioutil.ReadFile(answersFile)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read answers file: %s", err)
+		}
+		if err = json.Unmarshal(b, ans); err != nil {
+			return nil, fmt.Errorf("Answers file '%s' is invalid: %s", answersFile, err)
+		}
+	case ".conf":
+		cfg, err := config.LoadFile(answersFile)
+		if err != nil {
+			return nil, fmt.Errorf("Answers file '%s' is invalid: %s", answersFile, err)
+		}
+		ans = answersFromConfig(cfg, "")
+	default:
+		return nil, fmt.Errorf("Unsupported answers file '%s', use either '.conf' or '.json'", answersFile)
+	}
+	return ans, nil
+}
+
+// answersFromConfig method reads the answers from config for the given
+// key prefix, it's used by answers file and app template manifest.
+func answersFromConfig(cfg *config.Config, keyPrefix string) *appAnswers {
+	ans := &appAnswers{
+		ImportPath:    cfg.StringDefault(keyPrefix+"import_path", ""),
+		Dir:           cfg.StringDefault(keyPrefix+"dir", ""),
+		Type:          cfg.StringDefault(keyPrefix+"type", ""),
+		ViewEngine:    cfg.StringDefault(keyPrefix+"view_engine", ""),
+		AuthScheme:    cfg.StringDefault(keyPrefix+"auth_scheme", ""),
+		BasicAuthMode: cfg.StringDefault(keyPrefix+"basic_auth_mode", ""),
+		PasswordHash:  cfg.StringDefault(keyPrefix+"password_hash", ""),
+		SessionStore:  cfg.StringDefault(keyPrefix+"session_store", ""),
+		Database:      cfg.StringDefault(keyPrefix+"database", ""),
+		Vars:          make(map[string]string),
+
+		OAuth2Provider:        cfg.StringDefault(keyPrefix+"oauth2_provider", ""),
+		OAuth2ClientIDEnv:     cfg.StringDefault(keyPrefix+"oauth2_client_id_env", ""),
+		OAuth2ClientSecretEnv: cfg.StringDefault(keyPrefix+"oauth2_client_secret_env", ""),
+		OAuth2CallbackPath:    cfg.StringDefault(keyPrefix+"oauth2_callback_path", ""),
+		OAuth2AuthURL:         cfg.StringDefault(keyPrefix+"oauth2_auth_url", ""),
+		OAuth2TokenURL:        cfg.StringDefault(keyPrefix+"oauth2_token_url", ""),
+	}
+	if cors, found := cfg.Bool(keyPrefix + "cors"); found {
+		ans.CORS = &cors
+	}
+	if subTypes, found := cfg.StringList(keyPrefix + "sub_types"); found {
+		ans.SubTypes = append(make([]string, 0), subTypes...)
+	}
+	for _, key := range cfg.KeysByPath(keyPrefix + "vars") {
+		ans.Vars[key] = cfg.StringDefault(keyPrefix+"vars."+key, "")
+	}
+	return ans
+}
+
 func readInput(reader *bufio.Reader, prompt string) string {
 	fmt.Print(prompt)
 	input, err := reader.ReadString('\n')
@@ -107,136 +471,118 @@ func readInput(reader *bufio.Reader, prompt string) string {
 	return strings.TrimSpace(input)
 }
 
-func collectImportPath(reader *bufio.Reader) string {
-	var importPath string
-	for {
-		importPath = filepath.ToSlash(readInput(reader, "\nEnter your application import path: "))
-		if !ess.IsStrEmpty(importPath) {
-			if m := aahInventory.Lookup(importPath); m != nil {
-				logErrorf("Given import path '%s' already exists at '%s'", importPath, m.Dir)
-				importPath = ""
-				continue
-			} else {
-				if ess.IsImportPathExists(importPath) {
-					logErrorf("Given import path '%s' already exists in GOPATH", importPath)
-					importPath = ""
-					continue
-				}
-			}
-			break
+// collectInput method returns the answer given upfront after validating it,
+// invalid answer is fatal. Otherwise it prompts the user until valid input is
+// provided. In non-interactive mode, unanswered prompt takes its default value.
+func collectInput(reader *bufio.Reader, ans *appAnswers, answer, prompt string, parse func(string) (string, error)) string {
+	if !ess.IsStrEmpty(answer) || !ans.interactive {
+		v, err := parse(strings.TrimSpace(answer))
+		if err != nil {
+			logFatal(err)
 		}
+		return v
 	}
-	return strings.Replace(importPath, " ", "-", -1)
-}
 
-func collectAppDir(reader *bufio.Reader, importPath string) string {
-	var dir string
 	for {
-		dir = filepath.ToSlash(readInput(reader, "\nEnter your application location: "))
-		dir = filepath.Clean(dir)
-		if !ess.IsStrEmpty(dir) {
-			dir = filepath.Join(dir, path.Base(importPath))
-			if inferInsideGopath(dir) {
-				logError("Given directory is inside the GOPATH, it is highly recommneded to keep aah project outside the GOPATH")
-				dir = ""
-				continue
-			}
-			if ess.IsFileExists(dir) {
-				logErrorf("Given directory already exists at '%s'", dir)
-				dir = ""
-				continue
-			}
-			break
+		v, err := parse(readInput(reader, prompt))
+		if err == nil {
+			return v
 		}
+		logError(err)
 	}
-	return dir
 }
 
-func collectAppType(reader *bufio.Reader) string {
-	var appType string
-	for {
-		appType = strings.ToLower(readInput(reader, "\nChoose your application type (web, api or websocket), default is 'web': "))
-		if ess.IsStrEmpty(appType) || appType == typeWeb || appType == typeAPI || appType == typeWebSocket {
-			break
-		} else {
-			logError("Unsupported new aah application type, choose either 'web', 'api' or 'websocket'")
-			appType = ""
-		}
-	}
-	if ess.IsStrEmpty(appType) {
-		appType = typeWeb
-	}
-	return appType
+func collectImportPath(reader *bufio.Reader, ans *appAnswers) string {
+	return collectInput(reader, ans, ans.ImportPath, "\nEnter your application import path: ", parseImportPath)
+}
+
+func collectAppDir(reader *bufio.Reader, ans *appAnswers, importPath string) string {
+	return collectInput(reader, ans, ans.Dir, "\nEnter your application location: ", func(v string) (string, error) {
+		return parseAppDir(v, importPath)
+	})
+}
+
+func collectAppType(reader *bufio.Reader, ans *appAnswers) string {
+	return collectInput(reader, ans, ans.Type,
+		"\nChoose your application type (web, api or websocket), default is 'web': ", parseAppType)
 }
 
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // Collecting inputs for Web App
 //______________________________________________________________________________
 
-func collectInputsForWebApp(c *console.Context, app *appTmplData) {
-	viewEngine(reader, app)
+func collectInputsForWebApp(ans *appAnswers, app *appTmplData) {
+	viewEngine(reader, ans, app)
 
-	authScheme(reader, app)
+	authScheme(reader, ans, app)
 
 	if app.AuthScheme == authBasic {
-		basicAuthMode(reader, app)
+		basicAuthMode(reader, ans, app)
+	}
+
+	if app.AuthScheme == authOAuth2 {
+		oauth2Info(reader, ans, app)
 	}
 
-	passwordHashAlgorithm(reader, app)
+	passwordHashAlgorithm(reader, ans, app)
 
-	sessionInfo(reader, app)
+	sessionInfo(reader, ans, app)
 
 	// In the web application user may like to have API also WebSocket within it.
-	collectAppSubTypesChoice(c, reader, app)
+	collectAppSubTypesChoice(reader, ans, app)
 
-	app.CORSEnable = collectYesOrNo(reader, "Would you like to enable CORS? [y/N]")
+	databaseInfo(reader, ans, app)
+
+	app.CORSEnable = collectYesOrNoAnswer(reader, ans, ans.CORS, "Would you like to enable CORS? [y/N]")
 }
 
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // Collecting inputs for API App
 //______________________________________________________________________________
 
-func collectInputsForAPIApp(c *console.Context, app *appTmplData) {
-	authScheme(reader, app)
+func collectInputsForAPIApp(ans *appAnswers, app *appTmplData) {
+	authScheme(reader, ans, app)
 
 	if app.AuthScheme == authBasic {
-		basicAuthMode(reader, app)
+		basicAuthMode(reader, ans, app)
 	}
 
-	passwordHashAlgorithm(reader, app)
+	passwordHashAlgorithm(reader, ans, app)
+
+	databaseInfo(reader, ans, app)
 
-	app.CORSEnable = collectYesOrNo(reader, "Would you like to enable CORS? [y/N]")
+	app.CORSEnable = collectYesOrNoAnswer(reader, ans, ans.CORS, "Would you like to enable CORS? [y/N]")
 }
 
-func collectAppSubTypesChoice(c *console.Context, reader *bufio.Reader, app *appTmplData) {
+func collectAppSubTypesChoice(reader *bufio.Reader, ans *appAnswers, app *appTmplData) {
+	if ans.SubTypes != nil {
+		subTypes, err := parseAppSubTypes(ans.SubTypes)
+		if err != nil {
+			logFatal(err)
+		}
+		app.SubTypes = subTypes
+		return
+	}
+
 	app.SubTypes = make([]string, 0)
 
 	// API choice
-	choice := collectYesOrNo(reader, "Would you like to add API (/api/v1/*) within your Web App? [y/N]")
+	choice := collectYesOrNoAnswer(reader, ans, nil, "Would you like to add API (/api/v1/*) within your Web App? [y/N]")
 	if choice {
 		app.SubTypes = append(app.SubTypes, typeAPI)
 	}
 
 	// WebSocket choice
-	choice = collectYesOrNo(reader, "Would you like to add WebSocket (/ws/*) within your Web App? [y/N]")
+	choice = collectYesOrNoAnswer(reader, ans, nil, "Would you like to add WebSocket (/ws/*) within your Web App? [y/N]")
 	if choice {
 		app.SubTypes = append(app.SubTypes, typeWebSocket)
 	}
 }
 
-func viewEngine(reader *bufio.Reader, app *appTmplData) {
-	builtInViewEngines := []string{"go"}
-	var engine string
-	for {
-		engine = strings.ToLower(readInput(reader, fmt.Sprintf("\nChoose your application View Engine (%s), default is 'go': ",
-			strings.Join(builtInViewEngines, ", "))))
-		if ess.IsStrEmpty(engine) || ess.IsSliceContainsString(builtInViewEngines, engine) {
-			break
-		} else {
-			logErrorf("Unsupported View Engine")
-			engine = ""
-		}
-	}
+func viewEngine(reader *bufio.Reader, ans *appAnswers, app *appTmplData) {
+	engine := collectInput(reader, ans, ans.ViewEngine,
+		fmt.Sprintf("\nChoose your application View Engine (%s), default is 'go': ",
+			strings.Join(builtInViewEngines, ", ")), parseViewEngine)
 
 	switch engine {
 	case "pug":
@@ -248,87 +594,92 @@ func viewEngine(reader *bufio.Reader, app *appTmplData) {
 	}
 }
 
-func authScheme(reader *bufio.Reader, app *appTmplData) {
+func authScheme(reader *bufio.Reader, ans *appAnswers, app *appTmplData) {
 	var schemeNames string
 
 	if app.IsWebApp() {
-		schemeNames = "form, basic"
+		schemeNames = "form, basic, oauth2"
 	} else if app.IsAPIApp() {
-		schemeNames = "basic, generic"
+		schemeNames = "basic, generic, jwt"
 	}
 
-	for {
-		app.AuthScheme = strings.ToLower(readInput(reader, fmt.Sprintf("\nChoose your application Auth Scheme (%v), default is 'none': ", schemeNames)))
-		if isAuthSchemeSupported(app.AuthScheme) {
-			if ess.IsStrEmpty(app.AuthScheme) || app.AuthScheme == authNone ||
-				app.IsAuthSchemeForWeb() || app.IsAuthSchemeForAPI() {
-				break
-			} else {
-				logErrorf("Application type '%v' is not applicable with auth scheme '%v'", app.Type, app.AuthScheme)
-				app.AuthScheme = ""
-			}
-		} else {
-			logErrorf("Unsupported Auth Scheme")
-			app.AuthScheme = ""
-		}
-	}
+	app.AuthScheme = collectInput(reader, ans, ans.AuthScheme,
+		fmt.Sprintf("\nChoose your application Auth Scheme (%v), default is 'none': ", schemeNames),
+		func(v string) (string, error) {
+			return parseAuthScheme(v, app.Type)
+		})
+}
 
-	if app.AuthScheme == authNone {
-		app.AuthScheme = ""
-	}
+func basicAuthMode(reader *bufio.Reader, ans *appAnswers, app *appTmplData) {
+	app.BasicAuthMode = collectInput(reader, ans, ans.BasicAuthMode,
+		"\nChoose your basic auth mode (file-realm, dynamic), default is 'file-realm': ", parseBasicAuthMode)
 }
 
-func basicAuthMode(reader *bufio.Reader, app *appTmplData) {
-	for {
-		app.BasicAuthMode = strings.ToLower(readInput(reader, "\nChoose your basic auth mode (file-realm, dynamic), default is 'file-realm': "))
-		if ess.IsStrEmpty(app.BasicAuthMode) || app.BasicAuthMode == "dynamic" {
-			break
-		} else {
-			logError("Unsupported Basic auth mode")
-			app.BasicAuthMode = ""
-		}
-	}
+func oauth2Info(reader *bufio.Reader, ans *appAnswers, app *appTmplData) {
+	app.OAuth2Provider = collectInput(reader, ans, ans.OAuth2Provider,
+		"\nChoose your OAuth2 provider (github, google, oidc), default is 'github': ", parseOAuth2Provider)
+
+	envPrefix := strings.ToUpper(app.OAuth2Provider)
+	app.OAuth2ClientIDEnv = collectInput(reader, ans, ans.OAuth2ClientIDEnv,
+		fmt.Sprintf("\nEnter env variable name for OAuth2 client id, default is '%s_CLIENT_ID': ", envPrefix),
+		func(v string) (string, error) {
+			return parseEnvVarName(v, envPrefix+"_CLIENT_ID")
+		})
+	app.OAuth2ClientSecretEnv = collectInput(reader, ans, ans.OAuth2ClientSecretEnv,
+		fmt.Sprintf("\nEnter env variable name for OAuth2 client secret, default is '%s_CLIENT_SECRET': ", envPrefix),
+		func(v string) (string, error) {
+			return parseEnvVarName(v, envPrefix+"_CLIENT_SECRET")
+		})
 
-	if ess.IsStrEmpty(app.BasicAuthMode) {
-		app.BasicAuthMode = basicFileRealm
+	defaultCallback := "/auth/" + app.OAuth2Provider + "/callback"
+	app.OAuth2CallbackPath = collectInput(reader, ans, ans.OAuth2CallbackPath,
+		fmt.Sprintf("\nEnter OAuth2 callback path, default is '%s': ", defaultCallback),
+		func(v string) (string, error) {
+			return parseCallbackPath(v, defaultCallback)
+		})
+
+	// OIDC endpoints are specific to the issuer, no default for it
+	if app.OAuth2Provider == oauth2OIDC {
+		app.OAuth2ProviderAuthURL = collectInput(reader, ans, ans.OAuth2AuthURL,
+			"\nEnter your OIDC issuer authorization endpoint URL: ", func(v string) (string, error) {
+				return parseEndpointURL(v, "oauth2-auth-url")
+			})
+		app.OAuth2ProviderTokenURL = collectInput(reader, ans, ans.OAuth2TokenURL,
+			"\nEnter your OIDC issuer token endpoint URL: ", func(v string) (string, error) {
+				return parseEndpointURL(v, "oauth2-token-url")
+			})
 	}
 }
 
-func passwordHashAlgorithm(reader *bufio.Reader, app *appTmplData) {
+func passwordHashAlgorithm(reader *bufio.Reader, ans *appAnswers, app *appTmplData) {
 	if app.AuthScheme == authForm || app.AuthScheme == authBasic {
-		for {
-			app.PasswordEncoderAlgo = strings.ToLower(readInput(reader, "\nChoose your password hash algorithm (bcrypt, scrypt, pbkdf2), default is 'bcrypt': "))
-			if ess.IsStrEmpty(app.PasswordEncoderAlgo) || app.PasswordEncoderAlgo == "bcrypt" ||
-				app.PasswordEncoderAlgo == "scrypt" || app.PasswordEncoderAlgo == "pbkdf2" {
-				break
-			} else {
-				logError("Unsupported Password hash algorithm")
-				app.PasswordEncoderAlgo = ""
-			}
-		}
-
-		if ess.IsStrEmpty(app.PasswordEncoderAlgo) {
-			app.PasswordEncoderAlgo = "bcrypt"
-		}
+		app.PasswordEncoderAlgo = collectInput(reader, ans, ans.PasswordHash,
+			"\nChoose your password hash algorithm (bcrypt, scrypt, pbkdf2), default is 'bcrypt': ", parsePasswordHashAlgorithm)
 	}
 }
 
-func sessionInfo(reader *bufio.Reader, app *appTmplData) {
+func sessionInfo(reader *bufio.Reader, ans *appAnswers, app *appTmplData) {
 	if app.IsAuthSchemeForWeb() {
-		for {
-			app.SessionStore = strings.ToLower(readInput(reader, "\nChoose your session store (cookie or file), default is 'cookie': "))
-			if ess.IsStrEmpty(app.SessionStore) || app.SessionStore == storeCookie || app.SessionStore == storeFile {
-				break
-			} else {
-				logError("Unsupported session store type")
-				app.SessionStore = ""
-			}
-		}
+		app.SessionStore = collectInput(reader, ans, ans.SessionStore,
+			"\nChoose your session store (cookie or file), default is 'cookie': ", parseSessionStore)
+	}
+}
 
-		if ess.IsStrEmpty(app.SessionStore) {
-			app.SessionStore = storeCookie
-		}
+func databaseInfo(reader *bufio.Reader, ans *appAnswers, app *appTmplData) {
+	app.Database = collectInput(reader, ans, ans.Database,
+		"\nChoose your database (sqlite, postgres, mysql or none), default is 'sqlite': ", parseDatabase)
+}
+
+// collectYesOrNoAnswer method returns the answer given upfront otherwise
+// prompts the user. In non-interactive mode default is 'no'.
+func collectYesOrNoAnswer(reader *bufio.Reader, ans *appAnswers, answer *bool, msg string) bool {
+	if answer != nil {
+		return *answer
 	}
+	if !ans.interactive {
+		return false
+	}
+	return collectYesOrNo(reader, msg)
 }
 
 func collectYesOrNo(reader *bufio.Reader, msg string) bool {
@@ -349,21 +700,203 @@ func collectYesOrNo(reader *bufio.Reader, msg string) bool {
 	return input == "y"
 }
 
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Validating inputs, used by both interactive and non-interactive way
+//______________________________________________________________________________
+
+var builtInViewEngines = []string{"go"}
+
+func parseImportPath(importPath string) (string, error) {
+	importPath = filepath.ToSlash(importPath)
+	if ess.IsStrEmpty(importPath) {
+		return "", errors.New("Application import path is required")
+	}
+	if m := aahInventory.Lookup(importPath); m != nil {
+		return "", fmt.Errorf("Given import path '%s' already exists at '%s'", importPath, m.Dir)
+	}
+	if ess.IsImportPathExists(importPath) {
+		return "", fmt.Errorf("Given import path '%s' already exists in GOPATH", importPath)
+	}
+	return strings.Replace(importPath, " ", "-", -1), nil
+}
+
+func parseAppDir(dir, importPath string) (string, error) {
+	dir = filepath.ToSlash(dir)
+	if ess.IsStrEmpty(dir) {
+		return "", errors.New("Application location is required")
+	}
+	dir = filepath.Join(filepath.Clean(dir), path.Base(importPath))
+	if inferInsideGopath(dir) {
+		return "", errors.New("Given directory is inside the GOPATH, it is highly recommneded to keep aah project outside the GOPATH")
+	}
+	if ess.IsFileExists(dir) && !ess.IsDir(dir) {
+		return "", fmt.Errorf("Given location already exists as file at '%s'", dir)
+	}
+	return dir, nil
+}
+
+func parseAppType(appType string) (string, error) {
+	appType = strings.ToLower(appType)
+	switch appType {
+	case "":
+		return typeWeb, nil
+	case typeWeb, typeAPI, typeWebSocket:
+		return appType, nil
+	}
+	return "", errors.New("Unsupported new aah application type, choose either 'web', 'api' or 'websocket'")
+}
+
+func parseViewEngine(engine string) (string, error) {
+	engine = strings.ToLower(engine)
+	if ess.IsStrEmpty(engine) || ess.IsSliceContainsString(builtInViewEngines, engine) {
+		return engine, nil
+	}
+	return "", fmt.Errorf("Unsupported View Engine '%s'", engine)
+}
+
+func parseAuthScheme(scheme, appType string) (string, error) {
+	scheme = strings.ToLower(scheme)
+	if !isAuthSchemeSupported(scheme) {
+		return "", fmt.Errorf("Unsupported Auth Scheme '%s'", scheme)
+	}
+	if ess.IsStrEmpty(scheme) || scheme == authNone {
+		return "", nil
+	}
+	app := &appTmplData{Type: appType, AuthScheme: scheme}
+	if !app.IsAuthSchemeForWeb() && !app.IsAuthSchemeForAPI() {
+		return "", fmt.Errorf("Application type '%v' is not applicable with auth scheme '%v'", appType, scheme)
+	}
+	return scheme, nil
+}
+
+func parseBasicAuthMode(mode string) (string, error) {
+	mode = strings.ToLower(mode)
+	switch mode {
+	case "":
+		return basicFileRealm, nil
+	case basicFileRealm, "dynamic":
+		return mode, nil
+	}
+	return "", fmt.Errorf("Unsupported Basic auth mode '%s'", mode)
+}
+
+func parseOAuth2Provider(provider string) (string, error) {
+	provider = strings.ToLower(provider)
+	if ess.IsStrEmpty(provider) {
+		return oauth2GitHub, nil
+	}
+	if _, found := oauth2ProviderInfo[provider]; found {
+		return provider, nil
+	}
+	return "", fmt.Errorf("Unsupported OAuth2 provider '%s', choose either 'github', 'google' or 'oidc'", provider)
+}
+
+var envVarNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func parseEnvVarName(name, defaultName string) (string, error) {
+	if ess.IsStrEmpty(name) {
+		return defaultName, nil
+	}
+	if !envVarNameRegex.MatchString(name) {
+		return "", fmt.Errorf("Invalid environment variable name '%s'", name)
+	}
+	return name, nil
+}
+
+func parseCallbackPath(p, defaultPath string) (string, error) {
+	if ess.IsStrEmpty(p) {
+		return defaultPath, nil
+	}
+	if !strings.HasPrefix(p, "/") || strings.ContainsAny(p, " ?#") {
+		return "", fmt.Errorf("Invalid callback path '%s', it should be absolute URL path e.g. '/auth/callback'", p)
+	}
+	return path.Clean(p), nil
+}
+
+func parseEndpointURL(v, flagName string) (string, error) {
+	if ess.IsStrEmpty(v) {
+		return "", fmt.Errorf("OIDC provider endpoint URL is required, refer to the issuer's '/.well-known/openid-configuration' and use flag '--%s' in non-interactive mode", flagName)
+	}
+	u, err := url.Parse(v)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || ess.IsStrEmpty(u.Host) {
+		return "", fmt.Errorf("Invalid endpoint URL '%s', it should be absolute URL e.g. 'https://issuer.example.com/oauth2/token'", v)
+	}
+	return v, nil
+}
+
+func parsePasswordHashAlgorithm(algo string) (string, error) {
+	algo = strings.ToLower(algo)
+	switch algo {
+	case "":
+		return "bcrypt", nil
+	case "bcrypt", "scrypt", "pbkdf2":
+		return algo, nil
+	}
+	return "", fmt.Errorf("Unsupported Password hash algorithm '%s'", algo)
+}
+
+func parseSessionStore(store string) (string, error) {
+	store = strings.ToLower(store)
+	switch store {
+	case "":
+		return storeCookie, nil
+	case storeCookie, storeFile:
+		return store, nil
+	}
+	return "", fmt.Errorf("Unsupported session store type '%s'", store)
+}
+
+func parseDatabase(db string) (string, error) {
+	db = strings.ToLower(db)
+	switch db {
+	case "":
+		return dbSQLite, nil
+	case dbNone:
+		return "", nil
+	case dbSQLite, dbPostgres, dbMySQL:
+		return db, nil
+	}
+	return "", fmt.Errorf("Unsupported database '%s'", db)
+}
+
+// parseAppSubTypes method validates the sub types of flag or answers file,
+// 'none' and empty values are ignored same as interactive choice.
+func parseAppSubTypes(subTypes []string) ([]string, error) {
+	result := make([]string, 0)
+	for _, t := range subTypes {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if ess.IsStrEmpty(t) || t == "none" {
+			continue
+		}
+		if t != typeAPI && t != typeWebSocket {
+			return nil, fmt.Errorf("Unsupported sub type '%s', choose either 'api' or 'websocket'", t)
+		}
+		if !ess.IsSliceContainsString(result, t) {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
 type file struct {
 	src, dst string
+
+	// content is used instead of reading src, for built-in template files
+	content string
 }
 
-func createAahApp(appDir string, data map[string]interface{}) error {
-	app := data["App"].(*appTmplData)
+// appTmplFilePlan method returns the resolved list of app template files
+// with its destination for the given app inputs.
+func appTmplFilePlan(app *appTmplData) []file {
 	appBaseDir := app.BaseDir
-	appTmplBaseDir := inferAppTmplBaseDir()
+	appTmplBaseDir := app.tmplBaseDir
 	if ess.IsStrEmpty(appTmplBaseDir) {
-		logFatal("Unable to find aah app template at $HOME/.aah/app-templates")
+		logFatal("Unable to find aah app template, refer to 'aah help new' for app templates location")
 	}
 
-	// app directory creation
-	if err := ess.MkDirAll(appDir, permRWXRXRX); err != nil {
-		logFatal(err)
+	// template manifest drives the file selection
+	if app.manifest != nil {
+		return app.manifest.TmplFiles(app, appTmplBaseDir, appBaseDir)
 	}
 
 	files := make([]file, 0)
@@ -397,7 +930,20 @@ func createAahApp(appDir string, data map[string]interface{}) error {
 	files = append(files, sourceTmplFiles(app, appTmplBaseDir, appBaseDir)...)
 
 	// config
-	files = append(files, configTmplFiles(app.Type, appTmplBaseDir, appBaseDir)...)
+	files = append(files, configTmplFiles(app, appTmplBaseDir, appBaseDir)...)
+
+	// OAuth2 and JWT security config and providers are built-in
+	if app.IsOAuth2() {
+		files = append(files, oauth2TmplFiles(appBaseDir)...)
+	}
+	if app.IsJWT() {
+		files = append(files, jwtTmplFiles(appBaseDir)...)
+	}
+
+	// database layer is built-in
+	if app.IsDatabaseEnabled() {
+		files = append(files, dbTmplFiles(appBaseDir)...)
+	}
 
 	if app.IsWebApp() {
 		// i18n
@@ -410,20 +956,182 @@ func createAahApp(appDir string, data map[string]interface{}) error {
 		files = append(files, viewTmplFiles(app.ViewEngine, appTmplBaseDir, appBaseDir)...)
 	}
 
-	// processing app template files
+	return files
+}
+
+// createAahApp method renders the app template files into staging directory
+// and moves the finished tree into app directory only when all the files
+// processed successfully. On failure partial tree is rolled back.
+func createAahApp(app *appTmplData, files []file, data map[string]interface{}) error {
+	appDir := app.BaseDir
+	parentDir := filepath.Dir(appDir)
+	if err := ess.MkDirAll(parentDir, permRWXRXRX); err != nil {
+		return err
+	}
+
+	// staging directory is created next to app directory, so the move is rename
+	stagingDir, err := ioutil.TempDir(parentDir, "."+filepath.Base(appDir)+"-staging-")
+	if err != nil {
+		return fmt.Errorf("Unable to create staging directory: %s", err)
+	}
+	defer func() { _ = os.RemoveAll(stagingDir) }()
+
+	// processing app template files, collect all the errors
+	var errs []string
 	for _, f := range files {
-		processFile(appBaseDir, f, data)
+		rel, err := filepath.Rel(appDir, f.dst)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			errs = append(errs, fmt.Sprintf("%s: destination '%s' is outside of '%s'", f.src, f.dst, appDir))
+			continue
+		}
+		f.dst = filepath.Join(stagingDir, rel)
+		if err = processFile(stagingDir, f, data); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("Unable to create aah application, %d error(s) occurred:\n\t%s",
+			len(errs), strings.Join(errs, "\n\t"))
 	}
 
+	// database layer is built-in, template manifest does not generate it
+	if app.IsDatabaseEnabled() && app.manifest == nil {
+		if err = wireDatabase(app, stagingDir); err != nil {
+			return fmt.Errorf("Unable to wire database layer: %s", err)
+		}
+	}
+
+	// token controller is built-in, template manifest does not generate it
+	if app.IsJWT() && app.manifest == nil {
+		if err = wireJWTRoute(stagingDir); err != nil {
+			return fmt.Errorf("Unable to add JWT token route: %s", err)
+		}
+	}
+
+	// temp dir is created with 0700, staging dir becomes app dir on rename
+	if err = os.Chmod(stagingDir, permRWXRXRX); err != nil {
+		return err
+	}
+
+	return moveStagedTree(stagingDir, appDir)
+}
+
+// moveStagedTree method moves the staged files into app directory. Existing
+// files are backed up and restored if the move fails midway.
+func moveStagedTree(stagingDir, appDir string) error {
+	if !ess.IsFileExists(appDir) {
+		return os.Rename(stagingDir, appDir)
+	}
+
+	backupDir := stagingDir + "-backup"
+	defer func() { _ = os.RemoveAll(backupDir) }()
+
+	type moved struct{ dst, backup string }
+	var done []moved
+	rollback := func() {
+		for i := len(done) - 1; i >= 0; i-- {
+			_ = os.Remove(done[i].dst)
+			if !ess.IsStrEmpty(done[i].backup) {
+				_ = os.Rename(done[i].backup, done[i].dst)
+			}
+		}
+	}
+
+	stagedFiles, err := ess.FilesPath(stagingDir, true)
+	if err != nil {
+		return err
+	}
+	for _, sf := range stagedFiles {
+		rel, _ := filepath.Rel(stagingDir, sf)
+		m := moved{dst: filepath.Join(appDir, rel)}
+		if err = ess.MkDirAll(filepath.Dir(m.dst), permRWXRXRX); err != nil {
+			rollback()
+			return err
+		}
+		if ess.IsFileExists(m.dst) {
+			m.backup = filepath.Join(backupDir, rel)
+			if err = ess.MkDirAll(filepath.Dir(m.backup), permRWXRXRX); err == nil {
+				err = os.Rename(m.dst, m.backup)
+			}
+			if err != nil {
+				rollback()
+				return err
+			}
+		}
+		if err = os.Rename(sf, m.dst); err != nil {
+			if !ess.IsStrEmpty(m.backup) {
+				_ = os.Rename(m.backup, m.dst)
+			}
+			rollback()
+			return err
+		}
+		done = append(done, m)
+	}
 	return nil
 }
 
-func configTmplFiles(appType, appTmplBaseDir, appBaseDir string) []file {
+// appTmplConflicts method returns the rendered destinations which already
+// exists in the app base directory.
+func appTmplConflicts(files []file) []string {
+	var conflicts []string
+	for _, f := range files {
+		if dst := strings.TrimSuffix(f.dst, aahTmplExt); ess.IsFileExists(dst) {
+			conflicts = append(conflicts, dst)
+		}
+	}
+	return conflicts
+}
+
+// printAppTmplPlan method prints the template variables and file plan
+// for 'aah new --dry-run'.
+func printAppTmplPlan(app *appTmplData, files []file) {
+	fmt.Printf("\nTemplate variables (.App):\n")
+	av := reflect.ValueOf(app).Elem()
+	at := av.Type()
+	l := 0
+	for i := 0; i < at.NumField(); i++ {
+		if fl := len(at.Field(i).Name); fl > l {
+			l = fl
+		}
+	}
+	fmtStr := "    %-" + strconv.Itoa(l) + "s %v\n"
+	for i := 0; i < at.NumField(); i++ {
+		if sf := at.Field(i); sf.PkgPath == "" {
+			fmt.Printf(fmtStr, sf.Name, av.Field(i).Interface())
+		}
+	}
+
+	conflicts := appTmplConflicts(files)
+	fmt.Printf("\nFile plan (%d files) from '%s':\n", len(files), app.tmplBaseDir)
+	for _, f := range files {
+		dst := strings.TrimSuffix(f.dst, aahTmplExt)
+		action := "copy"
+		if strings.HasSuffix(f.src, aahTmplExt) {
+			action = "render"
+		}
+		if ess.IsSliceContainsString(conflicts, dst) {
+			action += ", conflict"
+		}
+		src := f.src
+		if !strings.HasPrefix(src, builtinTmplPrefix) {
+			src, _ = filepath.Rel(app.tmplBaseDir, f.src)
+		}
+		fmt.Printf("    %s\n        ==> %s (%s)\n", filepath.ToSlash(src), dst, action)
+	}
+
+	if len(conflicts) > 0 {
+		fmt.Printf("\n%d file(s) already exists, use '--force' to overwrite them.\n", len(conflicts))
+	}
+	fmt.Println("\nDry run, no files were written.")
+	fmt.Println()
+}
+
+func configTmplFiles(app *appTmplData, appTmplBaseDir, appBaseDir string) []file {
 	srcDir := filepath.Join(appTmplBaseDir, "config")
 	flist, _ := ess.FilesPath(srcDir, true)
 	files := []file{}
 	for _, f := range flist {
-		if appType == typeWebSocket && strings.HasSuffix(f, "security.conf.atmpl") {
+		if (app.IsWebSocketApp() || app.hasBuiltinSecurity()) && strings.HasSuffix(f, "security.conf.atmpl") {
 			continue
 		}
 		files = append(files, file{src: f, dst: filepath.Join(appBaseDir, f[len(appTmplBaseDir):])})
@@ -479,7 +1187,7 @@ func sourceTmplFiles(app *appTmplData, appTmplBaseDir, appBaseDir string) []file
 	}
 
 	// /app/security
-	if app.IsSecurityEnabled() && app.BasicAuthMode != basicFileRealm {
+	if app.IsSecurityEnabled() && app.BasicAuthMode != basicFileRealm && !app.hasBuiltinSecurity() {
 		fn(filepath.Join(appTmplBaseDir, "app", "security"), true)
 	}
 
@@ -505,75 +1213,198 @@ func tmplFiles(srcDir, appTmplBaseDir, appBaseDir string, recur bool) []file {
 	return files
 }
 
-func processFile(appBaseDir string, f file, data map[string]interface{}) {
+// processFile method renders the template file (.atmpl) or copies the file
+// as-is to its destination. Returned error carries the template file name
+// and line number.
+func processFile(appBaseDir string, f file, data map[string]interface{}) error {
 	dst := strings.TrimSuffix(f.dst, aahTmplExt)
 
 	// create dst dir if not exists
 	dstDir := filepath.Dir(dst)
 	if !ess.IsFileExists(dstDir) {
-		_ = ess.MkDirAll(dstDir, permRWXRXRX)
+		if err := ess.MkDirAll(dstDir, permRWXRXRX); err != nil {
+			return err
+		}
 	}
 
-	// open src and create dst
-	sf, _ := os.Open(f.src)
-	df, _ := os.Create(dst)
-	defer ess.CloseQuietly(df, sf)
+	var err error
+	b := []byte(f.content)
+	if ess.IsStrEmpty(f.content) {
+		if b, err = ioutil.ReadFile(f.src); err != nil {
+			return err
+		}
+	}
 
 	// render or write it directly
 	if strings.HasSuffix(f.src, aahTmplExt) {
-		sfbytes, _ := ioutil.ReadAll(sf)
 		var buf bytes.Buffer
-		if err := renderTmpl(&buf, string(sfbytes), data); err != nil {
-			logFatalf("Unable to process file '%s': %s", dst, err)
+		if err = renderNamedTmpl(&buf, f.src, string(b), data); err != nil {
+			return err
 		}
-		var err error
-		b := buf.Bytes()
+		b = buf.Bytes()
 		if strings.HasSuffix(dst, ".go") {
 			if b, err = format.Source(b); err != nil {
-				logFatalf("aah '%s' file format source error: %s", dst, err)
+				return fmt.Errorf("%s: format source error: %s", f.src, err)
 			}
 		}
-		_, _ = io.Copy(df, bytes.NewReader(b))
-	} else {
-		_, _ = io.Copy(df, sf)
 	}
 
-	_ = ess.ApplyFileMode(dst, permRWRWRW)
+	if err = ioutil.WriteFile(dst, b, permRWRWRW); err != nil {
+		return err
+	}
+	return ess.ApplyFileMode(dst, permRWRWRW)
 }
 
 func isAuthSchemeSupported(authScheme string) bool {
 	return ess.IsStrEmpty(authScheme) || authScheme == authForm || authScheme == authBasic ||
-		authScheme == authGeneric || authScheme == authNone
+		authScheme == authOAuth2 || authScheme == authGeneric || authScheme == authJWT ||
+		authScheme == authNone
 }
 
-const templateBranchName = "0.12.x"
+const (
+	templateBranchName = "0.12.x"
+	templateRepo       = "https://github.com/go-aah/app-templates.git"
+)
+
+// appTmplSource struct holds where aah app templates comes from. By default
+// templates are cloned/updated from aah app-templates repo into
+// '<aahpath>/app-templates'.
+//
+// It could be customized via env variables (used by 'new', 'migrate' and 'run')
+//
+//	AAH_TEMPLATES     - local app templates directory or tarball
+//	AAH_TEMPLATES_REF - branch, tag or commit to use from templates repo
+//	AAH_OFFLINE       - 'true' uses cached templates without network access
+//
+// Command 'new' flags '--template-dir', '--template-ref' and '--offline'
+// takes precedence over env variables.
+type appTmplSource struct {
+	Dir     string
+	Ref     string
+	Offline bool
+}
+
+var appTmplSrc = &appTmplSource{
+	Dir:     os.Getenv("AAH_TEMPLATES"),
+	Ref:     os.Getenv("AAH_TEMPLATES_REF"),
+	Offline: os.Getenv("AAH_OFFLINE") == "true",
+}
 
 func inferAppTmplBaseDir() string {
+	if !ess.IsStrEmpty(appTmplSrc.Dir) {
+		return localAppTmplBaseDir(appTmplSrc.Dir)
+	}
+
 	aahBasePath := aahPath()
-	baseDir := filepath.Join(aahBasePath, "app-templates", "generic")
+	baseDir := filepath.Join(aahBasePath, "app-templates", defaultAppTmplName)
 	gitBaseDir := filepath.Dir(baseDir)
-	if ess.IsFileExists(gitBaseDir) {
-		err1 := gitPull(gitBaseDir)
-		err2 := gitCheckout(gitBaseDir, templateBranchName)
-		if err1 == nil && err2 == nil {
-			return baseDir
-		}
+
+	tmplRef := appTmplSrc.Ref
+	if ess.IsStrEmpty(tmplRef) {
+		tmplRef = templateBranchName
 	}
 
-	if err := os.RemoveAll(gitBaseDir); err != nil {
+	if err := syncAppTmplRepo(templateRepo, gitBaseDir, tmplRef, !ess.IsStrEmpty(appTmplSrc.Ref)); err != nil {
 		logError(err)
 		return ""
 	}
-	tmplRepo := "https://github.com/go-aah/app-templates.git"
-	cliLog.Infof("Downloading aah quick start app templates from %s", tmplRepo)
-	gitArgs := []string{"clone", tmplRepo, gitBaseDir}
-	if _, err := execCmd(gitcmd, gitArgs, false); err != nil {
-		logErrorf("Unable to download aah app-template from %s", tmplRepo)
+	if appTmplSrc.Offline && !ess.IsFileExists(baseDir) {
+		logErrorf("Offline mode, unable to find cached aah app templates at '%s'", gitBaseDir)
 		return ""
 	}
-	if err := gitCheckout(gitBaseDir, templateBranchName); err != nil {
-		logError(err)
+	return baseDir
+}
+
+// syncAppTmplRepo method clones or updates the app templates git repository
+// into gitDir and checkouts the ref. Pinned ref (tag or commit) is fetched,
+// otherwise branch is pulled. In offline mode it uses only the cached copy.
+func syncAppTmplRepo(repoURL, gitDir, ref string, pinned bool) error {
+	if appTmplSrc.Offline {
+		if !ess.IsFileExists(gitDir) {
+			return fmt.Errorf("Offline mode, unable to find cached app templates at '%s'", gitDir)
+		}
+		if !ess.IsStrEmpty(ref) {
+			if err := gitCheckout(gitDir, ref); err != nil {
+				return fmt.Errorf("Offline mode, unable to checkout '%s' from cached app templates: %s", ref, err)
+			}
+		}
+		return nil
+	}
+
+	if ess.IsFileExists(gitDir) {
+		var err1, err2 error
+		if pinned {
+			// pinned tag or commit, HEAD becomes detached so fetch is enough
+			err1 = gitFetch(gitDir)
+		} else {
+			err1 = gitPull(gitDir)
+		}
+		if !ess.IsStrEmpty(ref) {
+			err2 = gitCheckout(gitDir, ref)
+		}
+		if err1 == nil && err2 == nil {
+			return nil
+		}
+	}
+
+	if err := os.RemoveAll(gitDir); err != nil {
+		return err
+	}
+	cliLog.Infof("Downloading app templates from %s", repoURL)
+	gitArgs := []string{"clone", repoURL, gitDir}
+	if _, err := execCmd(gitcmd, gitArgs, false); err != nil {
+		return fmt.Errorf("Unable to download app templates from %s", repoURL)
+	}
+	if !ess.IsStrEmpty(ref) {
+		return gitCheckout(gitDir, ref)
+	}
+	return nil
+}
+
+// localAppTmplBaseDir method returns the app templates base directory from
+// given local directory or tarball (.tar, .tar.gz, .tgz). Given location
+// could be either app-templates root or template directory itself.
+func localAppTmplBaseDir(src string) string {
+	src = absPath(src)
+	if !ess.IsFileExists(src) {
+		logErrorf("Unable to find aah app templates at '%s'", src)
 		return ""
 	}
-	return baseDir
+
+	dir := src
+	if !ess.IsDir(src) {
+		dir = filepath.Join(aahPath(), "app-templates-local", ess.StripExt(filepath.Base(src)))
+		ess.DeleteFiles(dir)
+		cliLog.Infof("Extracting aah app templates from %s", src)
+		if err := untar(dir, src); err != nil {
+			logErrorf("Unable to extract aah app templates from '%s': %s", src, err)
+			return ""
+		}
+	}
+
+	if baseDir := findAppTmplBaseDir(dir); !ess.IsStrEmpty(baseDir) {
+		return baseDir
+	}
+
+	logErrorf("Given location '%s' is not a valid aah app templates, unable to find '%s' or 'aah.project.atmpl'",
+		src, appTmplManifestName)
+	return ""
+}
+
+// findAppTmplBaseDir method looks for the template in the directory itself,
+// app-templates root 'generic' or tarball top level directory.
+func findAppTmplBaseDir(dir string) string {
+	candidates := []string{dir, filepath.Join(dir, defaultAppTmplName)}
+	if dirs, err := ess.DirsPath(dir, false); err == nil {
+		for _, d := range dirs {
+			candidates = append(candidates, d, filepath.Join(d, defaultAppTmplName))
+		}
+	}
+	for _, d := range candidates {
+		if ess.IsFileExists(filepath.Join(d, appTmplManifestName)) ||
+			ess.IsFileExists(filepath.Join(d, "aah.project.atmpl")) {
+			return d
+		}
+	}
+	return ""
 }
@@ -0,0 +1,71 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"aahframe.work/essentials"
+)
+
+// TestCreateAahAppManifest verifies the built-in database and JWT wiring is
+// not applied on template manifest app, since it does not generate them.
+func TestCreateAahAppManifest(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "aahnew")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	tmplDir := filepath.Join(tmpDir, "acme")
+	tmplFiles := map[string]string{
+		appTmplManifestName:  "name = \"acme\"\n",
+		"app/init.go":        "package main\n\nfunc init() {\n}\n",
+		"config/routes.conf": "domains {\n  localhost {\n    routes {\n    }\n  }\n}\n",
+	}
+	for name, content := range tmplFiles {
+		fpath := filepath.Join(tmplDir, filepath.FromSlash(name))
+		if err = ess.MkDirAll(filepath.Dir(fpath), permRWXRXRX); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(fpath, []byte(content), permRWRWRW); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	app := &appTmplData{
+		Name:        "myapp",
+		Type:        typeAPI,
+		AuthScheme:  authJWT,
+		Database:    "sqlite",
+		BaseDir:     filepath.Join(tmpDir, "myapp"),
+		tmplBaseDir: tmplDir,
+		manifest:    &appTmplManifest{Name: "acme"},
+	}
+	if err = createAahApp(app, appTmplFilePlan(app), map[string]interface{}{"App": app}); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range tmplFiles {
+		if name == appTmplManifestName {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(app.BaseDir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != content {
+			t.Errorf("%s: expected template content as-is, got:\n%s", name, b)
+		}
+	}
+	for _, name := range []string{"app/db", "config/db.conf", "config/aah.conf"} {
+		if ess.IsFileExists(filepath.Join(app.BaseDir, filepath.FromSlash(name))) {
+			t.Errorf("%s: not expected for template manifest app", name)
+		}
+	}
+}
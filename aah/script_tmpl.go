@@ -0,0 +1,118 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"aahframe.work"
+	"aahframe.work/console"
+	"aahframe.work/essentials"
+)
+
+const (
+	scriptTmplDirName = "templates"
+	scriptTmplExt     = ".tmpl"
+)
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Script templates and its override lookup
+//______________________________________________________________________________
+
+// scriptTemplates holds the built-in script templates by name, the same name
+// with '.tmpl' extension is used to look up the user override template.
+var scriptTemplates = map[string]string{
+	"systemd.service":        aahSystemdScriptTemplate,
+	"logrotate":              aahLogrotateScriptTemplate,
+	"Dockerfile.dev":         aahDockerDevScriptTemplate,
+	"Dockerfile.prod":        aahDockerProdScriptTemplate,
+	"k8s-deployment.yaml":    aahK8sDeploymentTemplate,
+	"k8s-service.yaml":       aahK8sServiceTemplate,
+	"k8s-ingress.yaml":       aahK8sIngressTemplate,
+	"k8s-configmap.yaml":     aahK8sConfigMapTemplate,
+	"kustomize-base.yaml":    aahKustomizeBaseTemplate,
+	"kustomize-overlay.yaml": aahKustomizeOverlayTemplate,
+	"docker-compose.yml":     aahComposeScriptTemplate,
+	"devcontainer.json":      aahDevContainerTemplate,
+}
+
+// scriptTemplateNames holds the template names used by each script target.
+var scriptTemplateNames = map[string][]string{
+	"systemd":      {"systemd.service", "logrotate"},
+	"docker":       {"Dockerfile.dev", "Dockerfile.prod"},
+	"k8s":          {"k8s-deployment.yaml", "k8s-service.yaml", "k8s-ingress.yaml", "k8s-configmap.yaml", "kustomize-base.yaml", "kustomize-overlay.yaml"},
+	"compose":      {"docker-compose.yml"},
+	"devcontainer": {"devcontainer.json"},
+}
+
+// scriptTemplateDirs method returns the override template directories in the
+// look up order, application '<app-base-dir>/.aah/templates' then
+// '<aahpath>/templates'.
+func scriptTemplateDirs(baseDir string) []string {
+	return []string{
+		filepath.Join(baseDir, ".aah", scriptTmplDirName),
+		filepath.Join(aahPath(), scriptTmplDirName),
+	}
+}
+
+// scriptTemplate method returns the script template for given name. User
+// override template is returned if exists otherwise built-in one.
+func scriptTemplate(name string) (string, error) {
+	builtin, found := scriptTemplates[name]
+	if !found {
+		return "", fmt.Errorf("Unknown script template '%s'", name)
+	}
+
+	for _, dir := range scriptTemplateDirs(aah.App().BaseDir()) {
+		tmplFile := filepath.Join(dir, name+scriptTmplExt)
+		if !ess.IsFileExists(tmplFile) {
+			continue
+		}
+		b, err := ioutil.ReadFile(tmplFile)
+		if err != nil {
+			return "", fmt.Errorf("Unable to read template '%s': %s", tmplFile, err)
+		}
+		cliLog.Infof("Using template: %s\n", tmplFile)
+		return string(b), nil
+	}
+
+	return builtin, nil
+}
+
+// ejectScriptTemplates method writes out the built-in templates of given
+// script target into '<app-base-dir>/.aah/templates' for customization.
+func ejectScriptTemplates(c *console.Context, scriptName string) error {
+	names, found := scriptTemplateNames[scriptName]
+	if !found {
+		return fmt.Errorf("Unsupported 'script' name, try one of these 'systemd', 'docker', 'k8s', 'compose', 'devcontainer'")
+	}
+
+	app, _ := initAppForScript(c)
+	tmplDir := scriptTemplateDirs(app.BaseDir())[0]
+	if err := ess.MkDirAll(tmplDir, permRWXRXRX); err != nil {
+		return fmt.Errorf("Unable to create directory '%s': %s", tmplDir, err)
+	}
+
+	var ejected []string
+	for _, name := range names {
+		destFile := filepath.Join(tmplDir, name+scriptTmplExt)
+		if checkAndConfirmOverwrite(c, destFile) {
+			continue
+		}
+		if err := ioutil.WriteFile(destFile, []byte(scriptTemplates[name]), permRWRWRW); err != nil {
+			return fmt.Errorf("Unable to write template '%s': %s", destFile, err)
+		}
+		ejected = append(ejected, destFile)
+	}
+
+	if len(ejected) > 0 {
+		cliLog.Infof("Ejected '%s' templates at \n\t%s\n", scriptName, strings.Join(ejected, "\n\t"))
+		cliLog.Infof("What's next, customize the templates then run 'aah generate script --name %s'\n", scriptName)
+	}
+	return nil
+}
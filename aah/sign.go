@@ -0,0 +1,261 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"aahframe.work/console"
+	"aahframe.work/essentials"
+)
+
+const (
+	signatureExt    = ".sig"
+	keyRefEnvPrefix = "env:"
+)
+
+var verifyCmd = console.Command{
+	Name:      "verify",
+	Usage:     "Verifies the signature of aah application build artifact",
+	ArgsUsage: "<artifact>",
+	Description: `Verifies the detached ed25519 signature '<artifact>.sig' of the build artifact
+	created by 'aah build --sign-key'. If the build manifest is present next to the
+	artifact, its signature and the artifact and SBOM checksums recorded in it are
+	verified too.
+
+	Public key is PEM (PKIX) encoded or base64 of raw 32 bytes key. It can be given as
+	file path or 'env:<VAR_NAME>' to read the key from environment variable.
+
+	Example:
+		aah verify build/aahwebsite-381eaa8-linux-amd64.zip --key release-pub.pem
+		AAH_VERIFY_KEY=env:RELEASE_PUB_KEY aah verify build/aahwebsite-381eaa8-linux-amd64.zip`,
+	Flags: []console.Flag{
+		console.StringFlag{
+			Name:   "key, k",
+			Usage:  "Public key file path or 'env:<VAR_NAME>'",
+			EnvVar: "AAH_VERIFY_KEY",
+		},
+	},
+	Action: verifyAction,
+}
+
+func verifyAction(c *console.Context) error {
+	artifact := strings.TrimSpace(c.Args().First())
+	if ess.IsStrEmpty(artifact) {
+		_ = console.ShowCommandHelp(c, "verify")
+		return nil
+	}
+	artifact = absPath(artifact)
+
+	keyRef := strings.TrimSpace(c.String("key"))
+	if ess.IsStrEmpty(keyRef) {
+		logFatalf("Public key is required, provide it via '--key' or 'AAH_VERIFY_KEY'")
+	}
+	pubKey, err := loadVerifyKey(keyRef)
+	if err != nil {
+		logFatal(err)
+	}
+
+	if err = verifyFileSignature(pubKey, artifact); err != nil {
+		logFatal(err)
+	}
+	cliLog.Infof("Signature verified: %s", artifact)
+
+	manifestFile := artifactSidecarName(artifact) + buildManifestSuffix
+	if ess.IsFileExists(manifestFile) {
+		if err = verifyFileSignature(pubKey, manifestFile); err != nil {
+			logFatal(err)
+		}
+		if err = verifyManifestChecksum(manifestFile, artifact); err != nil {
+			logFatal(err)
+		}
+		cliLog.Infof("Signature and checksums verified: %s", manifestFile)
+	}
+
+	cliLog.Infof("Verify successful\n")
+	return nil
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Signing methods
+//___________________________________
+
+// buildSignKey holds the ed25519 key used to sign the build artifacts, it's
+// nil if signing is not requested.
+var buildSignKey ed25519.PrivateKey
+
+// signFiles method writes detached ed25519 signature '<file>.sig' for each
+// given file.
+func signFiles(key ed25519.PrivateKey, files ...string) error {
+	for _, f := range files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			return fmt.Errorf("Signing is not supported for directory: %s", f)
+		}
+
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		if err = ioutil.WriteFile(f+signatureExt, ed25519.Sign(key, b), permRWRWRW); err != nil {
+			return fmt.Errorf("Unable to write signature: %s", err)
+		}
+		cliLog.Infof("|-- Signed: %s", filepath.Base(f))
+	}
+	return nil
+}
+
+func verifyFileSignature(key ed25519.PublicKey, file string) error {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	sig, err := ioutil.ReadFile(file + signatureExt)
+	if err != nil {
+		return fmt.Errorf("Unable to read signature: %s", err)
+	}
+	if !ed25519.Verify(key, b, sig) {
+		return fmt.Errorf("Signature verification failed: %s", file)
+	}
+	return nil
+}
+
+func verifyManifestChecksum(manifestFile, artifact string) error {
+	b, err := ioutil.ReadFile(manifestFile)
+	if err != nil {
+		return err
+	}
+	m := &buildManifest{}
+	if err = json.Unmarshal(b, m); err != nil {
+		return fmt.Errorf("Unable to parse build manifest: %s", err)
+	}
+	sum, err := pathChecksum(artifact)
+	if err != nil {
+		return err
+	}
+	if m.Artifact.SHA256 != sum {
+		return fmt.Errorf("Artifact checksum mismatch with build manifest, expected %s got %s", m.Artifact.SHA256, sum)
+	}
+
+	// SBOM is not signed, it's verified by its checksum in the signed manifest
+	if m.SBOM != nil {
+		sbomFile := filepath.Join(filepath.Dir(manifestFile), m.SBOM.Path)
+		if sum, err = pathChecksum(sbomFile); err != nil {
+			return fmt.Errorf("Unable to read SBOM: %s", err)
+		}
+		if m.SBOM.SHA256 != sum {
+			return fmt.Errorf("SBOM checksum mismatch with build manifest, expected %s got %s", m.SBOM.SHA256, sum)
+		}
+	}
+	return nil
+}
+
+// loadSignKey method loads ed25519 private key from file path or
+// 'env:<VAR_NAME>'. Key is PEM (PKCS#8) encoded e.g. created via
+// 'openssl genpkey -algorithm ed25519' or base64 of raw 32 bytes seed
+// or 64 bytes private key.
+func loadSignKey(keyRef string) (ed25519.PrivateKey, error) {
+	b, err := readKeyRef(keyRef)
+	if err != nil {
+		return nil, err
+	}
+
+	if blk, _ := pem.Decode(b); blk != nil {
+		key, err := x509.ParsePKCS8PrivateKey(blk.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to parse sign key: %s", err)
+		}
+		if edKey, ok := key.(ed25519.PrivateKey); ok {
+			return edKey, nil
+		}
+		return nil, errors.New("Sign key is not an ed25519 private key")
+	}
+
+	raw, err := decodeRawKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse sign key: %s", err)
+	}
+	switch len(raw) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(raw), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(raw), nil
+	}
+	return nil, fmt.Errorf("Invalid sign key size %d bytes", len(raw))
+}
+
+// loadVerifyKey method loads ed25519 public key from file path or
+// 'env:<VAR_NAME>'. Key is PEM (PKIX) encoded or base64 of raw 32 bytes.
+func loadVerifyKey(keyRef string) (ed25519.PublicKey, error) {
+	b, err := readKeyRef(keyRef)
+	if err != nil {
+		return nil, err
+	}
+
+	if blk, _ := pem.Decode(b); blk != nil {
+		key, err := x509.ParsePKIXPublicKey(blk.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to parse public key: %s", err)
+		}
+		if edKey, ok := key.(ed25519.PublicKey); ok {
+			return edKey, nil
+		}
+		return nil, errors.New("Public key is not an ed25519 key")
+	}
+
+	raw, err := decodeRawKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse public key: %s", err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("Invalid public key size %d bytes", len(raw))
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+func readKeyRef(keyRef string) ([]byte, error) {
+	if strings.HasPrefix(keyRef, keyRefEnvPrefix) {
+		name := strings.TrimPrefix(keyRef, keyRefEnvPrefix)
+		v := strings.TrimSpace(os.Getenv(name))
+		if ess.IsStrEmpty(v) {
+			return nil, fmt.Errorf("Key environment variable '%s' is empty", name)
+		}
+		return []byte(v), nil
+	}
+
+	b, err := ioutil.ReadFile(absPath(keyRef))
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read key: %s", err)
+	}
+	return b, nil
+}
+
+// decodeRawKey method decodes base64 encoded key otherwise returns as-is for
+// raw key bytes.
+func decodeRawKey(b []byte) ([]byte, error) {
+	s := string(bytes.TrimSpace(b))
+	if raw, err := base64.StdEncoding.DecodeString(s); err == nil {
+		return raw, nil
+	}
+	switch len(b) {
+	case ed25519.SeedSize, ed25519.PrivateKeySize:
+		return b, nil
+	}
+	return nil, errors.New("key is neither PEM, base64 nor raw bytes")
+}
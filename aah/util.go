@@ -5,8 +5,10 @@
 package main
 
 import (
+	"archive/tar"
 	"bufio"
 	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
 	"go/build"
@@ -47,6 +49,32 @@ func inferGo111AndAbove() bool {
 	return verNum >= float64(1.11)
 }
 
+// inferGoVersionAtLeast method returns true if installed go version is
+// greater than or equal to given major and minor version.
+func inferGoVersionAtLeast(major, minor int) bool {
+	return isGoVersionAtLeast(goVersion(), major, minor)
+}
+
+// isGoVersionAtLeast method returns true if given go version e.g. '1.16',
+// '1.21.3' is same or above the major and minor.
+func isGoVersionAtLeast(ver string, major, minor int) bool {
+	parts := strings.Split(ver, ".")
+	if len(parts) < 2 {
+		return false
+	}
+	maj, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return false
+	}
+	min, err := strconv.Atoi(strings.TrimRightFunc(parts[1], func(r rune) bool {
+		return r < '0' || r > '9'
+	}))
+	if err != nil {
+		return false
+	}
+	return maj > major || (maj == major && min >= minor)
+}
+
 func inferInsideGopath(dir string) bool {
 	for _, gp := range filepath.SplitList(build.Default.GOPATH) {
 		if strings.HasPrefix(dir, gp) {
@@ -182,7 +210,8 @@ func getAppVersion(appBaseDir string, cfg *config.Config) string {
 // Application build date value priority are -
 // 		1. Env variable - AAH_APP_BUILD_TIMESTAMP
 // 		2. Env variable - AAH_APP_BUILD_DATE (deprecated in v0.12.0, highly recommended to use timestamp)
-// 		3. Created with time.Now().Format(time.RFC3339)
+// 		3. Reproducible build epoch, refer to 'initReproducibleBuild'
+// 		4. Created with time.Now().Format(time.RFC3339)
 func getBuildTimestamp() string {
 	// From env variable
 	if buildTimestamp := os.Getenv("AAH_APP_BUILD_TIMESTAMP"); !ess.IsStrEmpty(buildTimestamp) {
@@ -191,9 +220,56 @@ func getBuildTimestamp() string {
 	if buildDate := os.Getenv("AAH_APP_BUILD_DATE"); !ess.IsStrEmpty(buildDate) {
 		return buildDate
 	}
+	if reproducible.Enabled {
+		return reproducible.Epoch.Format(time.RFC3339)
+	}
 	return time.Now().Format(time.RFC3339)
 }
 
+// reproducible holds the reproducible build state. On enabled, build timestamp,
+// VFS and archive entry mod times are normalized to the epoch.
+var reproducible struct {
+	Enabled bool
+	Epoch   time.Time
+}
+
+// initReproducibleBuild method enables the reproducible build. Epoch value
+// priority are -
+// 		1. Env variable - SOURCE_DATE_EPOCH (https://reproducible-builds.org/specs/source-date-epoch/)
+// 		2. Last git commit timestamp
+// 		3. 1980-01-01T00:00:00Z (earliest time zip format can represent)
+func initReproducibleBuild(appBaseDir string) {
+	reproducible.Enabled = true
+	reproducible.Epoch = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if epoch := strings.TrimSpace(os.Getenv("SOURCE_DATE_EPOCH")); !ess.IsStrEmpty(epoch) {
+		sec, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			logFatalf("Invalid SOURCE_DATE_EPOCH value '%s': %s", epoch, err)
+		}
+		reproducible.Epoch = time.Unix(sec, 0).UTC()
+		return
+	}
+
+	if ess.IsFileExists(filepath.Join(appBaseDir, ".git")) {
+		output, err := execCmd(gitcmd, []string{"-C", appBaseDir, "log", "-1", "--format=%ct"}, false)
+		if err == nil {
+			if sec, err := strconv.ParseInt(strings.TrimSpace(output), 10, 64); err == nil {
+				reproducible.Epoch = time.Unix(sec, 0).UTC()
+			}
+		}
+	}
+}
+
+// modTime method returns the normalized mod time on reproducible build
+// otherwise given one.
+func modTime(t time.Time) time.Time {
+	if reproducible.Enabled {
+		return reproducible.Epoch
+	}
+	return t
+}
+
 func execCmd(cmdName string, args []string, stdout bool) (string, error) {
 	cmd := exec.Command(cmdName, args...) // #nosec
 	cliLog = initCLILogger(nil)
@@ -218,30 +294,79 @@ func execCmd(cmdName string, args []string, stdout bool) (string, error) {
 }
 
 func renderTmpl(w io.Writer, text string, data interface{}) error {
-	tmpl := template.Must(template.New("").Funcs(appTemplateFuncs).Parse(text))
+	return renderNamedTmpl(w, "", text, data)
+}
+
+// renderNamedTmpl method renders the template text, given name is reported
+// in the parse and execute errors along with line number.
+func renderNamedTmpl(w io.Writer, name, text string, data interface{}) error {
+	tmpl, err := template.New(name).Funcs(appTemplateFuncs).Parse(text)
+	if err != nil {
+		return err
+	}
 	return tmpl.Execute(w, data)
 }
 
-// appBinaryFile method binary file path creation
-func appBinaryFile(buildCfg *config.Config, appBuildDir string) string {
+// appBinaryFile method binary file path creation, binary name gets '.exe'
+// suffix for 'windows' target.
+func appBinaryFile(buildCfg *config.Config, appBuildDir, goos string) string {
 	replacer := strings.NewReplacer(" ", "_", ".", "_")
 	appBinaryName := buildCfg.StringDefault("build.binary_name", replacer.Replace(aah.App().Name()))
-	if isWindowsOS() {
+	if goos == "windows" {
 		appBinaryName += ".exe"
 	}
 	return filepath.Join(appBuildDir, "bin", appBinaryName)
 }
 
-func addTargetBuildInfo(name string) string {
-	if goos := getGOOS(); !ess.IsStrEmpty(goos) {
-		name += "-" + strings.ToLower(goos)
+func addTargetBuildInfo(name string, target buildTarget) string {
+	if !ess.IsStrEmpty(target.GOOS) {
+		name += "-" + strings.ToLower(target.GOOS)
 	}
-	if goarch := getGOARCH(); !ess.IsStrEmpty(goarch) {
-		name += "-" + strings.ToLower(goarch)
+	if !ess.IsStrEmpty(target.GOARCH) {
+		name += "-" + strings.ToLower(target.GOARCH)
 	}
 	return name
 }
 
+// buildTarget struct holds the cross compilation target.
+type buildTarget struct {
+	GOOS   string
+	GOARCH string
+}
+
+func (t buildTarget) String() string {
+	return t.GOOS + "/" + t.GOARCH
+}
+
+func hostBuildTarget() buildTarget {
+	return buildTarget{GOOS: getGOOS(), GOARCH: getGOARCH()}
+}
+
+// parseBuildTargets method parses the targets in the format of 'goos/goarch',
+// duplicates are ignored.
+func parseBuildTargets(values []string) ([]buildTarget, error) {
+	var targets []buildTarget
+	found := make(map[buildTarget]bool)
+	for _, v := range values {
+		for _, t := range strings.Split(v, ",") {
+			t = strings.TrimSpace(t)
+			if ess.IsStrEmpty(t) {
+				continue
+			}
+			parts := strings.Split(strings.ToLower(t), "/")
+			if len(parts) != 2 || ess.IsStrEmpty(parts[0]) || ess.IsStrEmpty(parts[1]) {
+				return nil, fmt.Errorf("Invalid build target '%s', expected format is 'goos/goarch'", t)
+			}
+			target := buildTarget{GOOS: parts[0], GOARCH: parts[1]}
+			if !found[target] {
+				found[target] = true
+				targets = append(targets, target)
+			}
+		}
+	}
+	return targets, nil
+}
+
 func isWindowsOS() bool {
 	return getGOOS() == "windows"
 }
@@ -329,6 +454,14 @@ func gitPull(dir string) error {
 	return nil
 }
 
+func gitFetch(dir string) error {
+	if ess.IsFileExists(filepath.Join(dir, ".git")) {
+		_, err := execCmd(gitcmd, []string{"-C", dir, "fetch", "--all", "--tags"}, false)
+		return err
+	}
+	return nil
+}
+
 func gitCheckout(dir, branch string) error {
 	if ess.IsFileExists(filepath.Join(dir, ".git")) {
 		_, err := execCmd(gitcmd, []string{"-C", dir, "checkout", branch}, false)
@@ -449,6 +582,33 @@ func toLowerCamelCase(v string) string {
 	return string(st)
 }
 
+// toSnakeCase method converts the camel case into lower snake case,
+// e.g. BlogPost => blog_post.
+func toSnakeCase(v string) string {
+	var st []byte
+	for idx := 0; idx < len(v); idx++ {
+		c := v[idx]
+		if c >= 'A' && c <= 'Z' {
+			if idx > 0 && v[idx-1] != '_' && !(v[idx-1] >= 'A' && v[idx-1] <= 'Z') {
+				st = append(st, '_')
+			}
+			c += 'a' - 'A'
+		}
+		st = append(st, c)
+	}
+	return string(st)
+}
+
+// toExportedName method converts the given name into exported Go identifier,
+// e.g. blog_post => BlogPost.
+func toExportedName(v string) string {
+	v = toLowerCamelCase(strings.Trim(strings.Replace(v, "-", "_", -1), "_ "))
+	if len(v) == 0 {
+		return v
+	}
+	return strings.ToUpper(v[:1]) + v[1:]
+}
+
 func aahPath() string {
 	s := os.Getenv("AAHPATH")
 	if s == "" {
@@ -484,6 +644,68 @@ func goCmdName() string {
 	return "go"
 }
 
+// untar method extracts given tarball (.tar, .tar.gz, .tgz) into
+// directory dst.
+func untar(dst, src string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer ess.CloseQuietly(f)
+
+	var r io.Reader = f
+	if strings.HasSuffix(src, ".gz") || strings.HasSuffix(src, ".tgz") {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer ess.CloseQuietly(gr)
+		r = gr
+	}
+
+	dst = filepath.Clean(dst)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dst, filepath.FromSlash(hdr.Name))
+		if target == dst {
+			// root entry './' of archive created via 'tar -C dir .'
+			continue
+		}
+		if !strings.HasPrefix(target, dst+string(filepath.Separator)) {
+			return fmt.Errorf("illegal file path in tarball: %s", hdr.Name)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err = ess.MkDirAll(target, permRWXRXRX); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err = ess.MkDirAll(filepath.Dir(target), permRWXRXRX); err != nil {
+				return err
+			}
+			tf, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR|os.O_TRUNC, os.FileMode(hdr.Mode))
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(tf, tr)
+			ess.CloseQuietly(tf)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func fetchURL(srcURL string) (*bytes.Buffer, error) {
 	resp, err := http.Get(srcURL)
 	if err != nil {
@@ -494,3 +716,25 @@ func fetchURL(srcURL string) (*bytes.Buffer, error) {
 	_, err = io.Copy(&b, resp.Body)
 	return &b, err
 }
+
+// addConfInclude method appends the include directive for the given config
+// file name into the config file if not exists already.
+func addConfInclude(filename, includeName, comment string) error {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	directive := fmt.Sprintf("include \"./%s\"", includeName)
+	if bytes.Contains(b, []byte(directive)) {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	buf.Write(bytes.TrimRight(b, "\n"))
+	buf.WriteString("\n\n")
+	for _, line := range strings.Split(comment, "\n") {
+		buf.WriteString("# " + line + "\n")
+	}
+	buf.WriteString(directive + "\n")
+	return ioutil.WriteFile(filename, buf.Bytes(), permRWRWRW)
+}
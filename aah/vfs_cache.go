@@ -0,0 +1,269 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"aahframe.work/essentials"
+)
+
+const (
+	vfsCacheDirName     = "vfs-cache"
+	vfsCacheRawMarker   = ".raw"
+	vfsCacheGzipExt     = ".gz"
+	vfsCacheMountSumExt = ".sum"
+	vfsCacheMountObjExt = ".objects"
+	vfsCacheMountBlbExt = ".blob"
+	vfsCacheMountVarExt = ".variants"
+)
+
+// vfsCacheStore holds the VFS content cache of current build, it's nil if
+// cache is disabled via 'vfs.cache = false' in 'aah.project'.
+var vfsCacheStore *vfsCache
+
+// vfsCacheBypass is true on rebuild of 'aah build --verify', VFS sources are
+// generated again instead of reusing the cache.
+var vfsCacheBypass bool
+
+// vfsCache struct is a content-hash cache for single binary build. It keeps
+// the gzipped and precompressed variant bytes of files by SHA-256 of its
+// content and generated VFS source of each mount by fingerprint of the mount
+// file tree. Cache lives at '<aahpath>/vfs-cache/<app-base-dir-hash>'.
+type vfsCache struct {
+	dir       string
+	used      map[string]bool
+	mountUsed map[string]bool
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// VFS cache methods
+//___________________________________
+
+func newVFSCache(appBaseDir string) *vfsCache {
+	return &vfsCache{dir: vfsCacheDir(appBaseDir), used: make(map[string]bool)}
+}
+
+func vfsCacheDir(appBaseDir string) string {
+	sum := sha256.Sum256([]byte(filepath.ToSlash(appBaseDir)))
+	return filepath.Join(aahPath(), vfsCacheDirName, hex.EncodeToString(sum[:8]))
+}
+
+// MountSource method returns the cached VFS source and data blob (if any) of
+// mount if fingerprint matches. It's called at the beginning of each mount
+// processing.
+func (vc *vfsCache) MountSource(vroot, fingerprint string) ([]byte, []byte, bool) {
+	vc.mountUsed = make(map[string]bool)
+	name := vc.mountFile(vroot)
+	sum, err := ioutil.ReadFile(name + vfsCacheMountSumExt)
+	if err != nil || string(sum) != fingerprint {
+		return nil, nil, false
+	}
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, nil, false
+	}
+	var blob []byte
+	if ess.IsFileExists(name + vfsCacheMountBlbExt) {
+		if blob, err = ioutil.ReadFile(name + vfsCacheMountBlbExt); err != nil {
+			return nil, nil, false
+		}
+	}
+
+	// file objects of the mount are still in use
+	if objs, err := ioutil.ReadFile(name + vfsCacheMountObjExt); err == nil {
+		for _, sum := range strings.Fields(string(objs)) {
+			vc.used[sum] = true
+		}
+	}
+	return b, blob, true
+}
+
+// MountVariants method returns the cached precompressed variant encodings of
+// mount by VFS path. It's called on cache hit of mount source.
+func (vc *vfsCache) MountVariants(vroot string) (map[string][]string, bool) {
+	b, err := ioutil.ReadFile(vc.mountFile(vroot) + vfsCacheMountVarExt)
+	if err != nil {
+		return nil, false
+	}
+	var variants map[string][]string
+	if err = json.Unmarshal(b, &variants); err != nil {
+		return nil, false
+	}
+	return variants, true
+}
+
+// SaveMountSource method stores the generated VFS source, data blob (if any)
+// and precompressed variant encodings of mount along with its fingerprint.
+func (vc *vfsCache) SaveMountSource(vroot, fingerprint string, b, blob []byte, variants map[string][]string) {
+	name := vc.mountFile(vroot)
+	if err := ess.MkDirAll(filepath.Dir(name), permRWXRXRX); err != nil {
+		cliLog.Warnf("vfs cache: %s", err)
+		return
+	}
+	if err := ioutil.WriteFile(name, b, permRWRWRW); err != nil {
+		cliLog.Warnf("vfs cache: %s", err)
+		return
+	}
+	ess.DeleteFiles(name + vfsCacheMountBlbExt)
+	if blob != nil {
+		if err := ioutil.WriteFile(name+vfsCacheMountBlbExt, blob, permRWRWRW); err != nil {
+			cliLog.Warnf("vfs cache: %s", err)
+			return
+		}
+	}
+	vb, err := json.Marshal(variants)
+	if err != nil {
+		cliLog.Warnf("vfs cache: %s", err)
+		return
+	}
+	if err = ioutil.WriteFile(name+vfsCacheMountVarExt, vb, permRWRWRW); err != nil {
+		cliLog.Warnf("vfs cache: %s", err)
+		return
+	}
+	var objs []string
+	for sum := range vc.mountUsed {
+		objs = append(objs, sum)
+	}
+	sort.Strings(objs)
+	_ = ioutil.WriteFile(name+vfsCacheMountObjExt, []byte(strings.Join(objs, "\n")), permRWRWRW)
+	_ = ioutil.WriteFile(name+vfsCacheMountSumExt, []byte(fingerprint), permRWRWRW)
+}
+
+// GzipBytes method returns the cached gzip bytes for the content sum. The
+// returned bytes is nil with found true if gzip has no benefit for content.
+func (vc *vfsCache) GzipBytes(sum string) ([]byte, bool) {
+	return vc.objectBytes(sum, vfsCacheGzipExt, "")
+}
+
+// SaveGzipBytes method stores gzip bytes for the content sum, nil bytes
+// records that gzip has no benefit for the content.
+func (vc *vfsCache) SaveGzipBytes(sum string, b []byte) {
+	vc.saveObjectBytes(sum, vfsCacheGzipExt, "", b)
+}
+
+// VariantBytes method returns the cached precompressed variant bytes for the
+// content sum and object extension. The returned bytes is nil with found true
+// if compression has no benefit for content.
+func (vc *vfsCache) VariantBytes(sum, ext string) ([]byte, bool) {
+	return vc.objectBytes(sum, ext, ext)
+}
+
+// SaveVariantBytes method stores precompressed variant bytes for the content
+// sum and object extension, nil bytes records that compression has no
+// benefit for the content.
+func (vc *vfsCache) SaveVariantBytes(sum, ext string, b []byte) {
+	vc.saveObjectBytes(sum, ext, ext, b)
+}
+
+// Prune method removes the cached file objects not used by current build,
+// so the cache does not grow unbounded.
+func (vc *vfsCache) Prune() {
+	objectsDir := filepath.Join(vc.dir, "objects")
+	_ = filepath.Walk(objectsDir, func(fpath string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		sum := strings.SplitN(info.Name(), ".", 2)[0]
+		if !vc.used[sum] {
+			ess.DeleteFiles(fpath)
+		}
+		return nil
+	})
+}
+
+func (vc *vfsCache) objectBytes(sum, ext, rawPrefix string) ([]byte, bool) {
+	name := vc.objectFile(sum)
+	vc.markUsed(sum)
+	if ess.IsFileExists(name + rawPrefix + vfsCacheRawMarker) {
+		return nil, true
+	}
+	b, err := ioutil.ReadFile(name + ext)
+	if err != nil {
+		return nil, false
+	}
+	return b, true
+}
+
+func (vc *vfsCache) saveObjectBytes(sum, ext, rawPrefix string, b []byte) {
+	name := vc.objectFile(sum)
+	vc.markUsed(sum)
+	if err := ess.MkDirAll(filepath.Dir(name), permRWXRXRX); err != nil {
+		cliLog.Warnf("vfs cache: %s", err)
+		return
+	}
+	var err error
+	if b == nil {
+		err = ioutil.WriteFile(name+rawPrefix+vfsCacheRawMarker, []byte{}, permRWRWRW)
+	} else {
+		err = ioutil.WriteFile(name+ext, b, permRWRWRW)
+	}
+	if err != nil {
+		cliLog.Warnf("vfs cache: %s", err)
+	}
+}
+
+func (vc *vfsCache) markUsed(sum string) {
+	vc.used[sum] = true
+	if vc.mountUsed != nil {
+		vc.mountUsed[sum] = true
+	}
+}
+
+func (vc *vfsCache) mountFile(vroot string) string {
+	return filepath.Join(vc.dir, "mounts", fmt.Sprintf("aah%s_vfs.go", strings.Replace(vroot, "/", "_", -1)))
+}
+
+func (vc *vfsCache) objectFile(sum string) string {
+	return filepath.Join(vc.dir, "objects", sum[:2], sum)
+}
+
+// contentSum method returns SHA-256 of the reader content and rewinds it.
+func contentSum(r io.ReadSeeker) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// vfsMountFingerprint method computes the fingerprint of mount from its
+// settings and file tree (path, size, mode and mod time). Any change in the
+// mount results in a different fingerprint.
+func vfsMountFingerprint(vroot, proot, embedMode string, noGzipList []string, dirs []string, files map[string]os.FileInfo, fnames []string) string {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "cli:%s\nvroot:%s\nproot:%s\nembed:%s\nnogzip:%s\ngzipmin:%d\n",
+		Version, vroot, proot, embedMode, strings.Join(noGzipList, ","), defaultGzipMinSize)
+	if reproducible.Enabled {
+		_, _ = fmt.Fprintf(h, "epoch:%d\n", reproducible.Epoch.Unix())
+	}
+	if assetBuildResult != nil {
+		_, _ = fmt.Fprintf(h, "assets:%s\n", assetBuildResult)
+	}
+	if vfsPrecompressPolicy != nil {
+		_, _ = fmt.Fprintf(h, "precompress:%s\n", vfsPrecompressPolicy)
+	}
+	_, _ = fmt.Fprintf(h, "index:%t\n", vfsIndexEnabled)
+	for _, d := range dirs {
+		_, _ = fmt.Fprintf(h, "d:%s\n", d)
+	}
+	for _, fname := range fnames {
+		info := files[fname]
+		_, _ = fmt.Fprintf(h, "f:%s:%d:%s:%d\n", fname, info.Size(), info.Mode(), info.ModTime().UnixNano())
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
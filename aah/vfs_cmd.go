@@ -0,0 +1,401 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"aahframe.work/console"
+	"aahframe.work/essentials"
+)
+
+var vfsCmd = console.Command{
+	Name:  "vfs",
+	Usage: "Inspects the embedded files of aah single binary or generated VFS sources",
+	Description: `Command vfs is to inspect what was embedded into aah single binary build
+	without running the application. It reads the mount index which is kept within
+	binary outside of VFS by 'aah build --single', unless 'vfs.index = false'.
+
+	Source is aah application binary, application base directory or generated
+	directory ('<app-base-dir>/app/generated').
+
+	To know more about individual sub-commands details:
+		aah vfs help ls
+		aah vfs help cat
+		aah vfs help stat
+		aah vfs help diff`,
+	Subcommands: []console.Command{
+		{
+			Name:      "ls",
+			Usage:     "Lists the embedded mounts, directories and files",
+			ArgsUsage: "<binary-or-generated-dir>",
+			Description: `Lists the embedded mounts, directories and files with size, stored size,
+	compression and mod time.
+
+	Example:
+		aah vfs ls build/bin/aahwebsite
+		aah vfs ls build/bin/aahwebsite --mount /app/static`,
+			Flags: []console.Flag{
+				console.StringFlag{
+					Name:  "mount, m",
+					Usage: "Lists only the given mount path or path prefix",
+				},
+			},
+			Action: vfsLsAction,
+		},
+		{
+			Name:      "cat",
+			Usage:     "Prints the embedded file content",
+			ArgsUsage: "<binary-or-generated-dir> <vfs-path>",
+			Description: `Prints the embedded file content, compressed content is decompressed.
+
+	Example:
+		aah vfs cat build/bin/aahwebsite /app/config/aah.conf
+		aah vfs cat build/bin/aahwebsite /app/static/css/app.css.br --raw > app.css.br`,
+			Flags: []console.Flag{
+				console.BoolFlag{
+					Name:  "raw",
+					Usage: "Prints the stored bytes as-is without decompression",
+				},
+			},
+			Action: vfsCatAction,
+		},
+		{
+			Name:      "stat",
+			Usage:     "Prints the embedded directory or file details",
+			ArgsUsage: "<binary-or-generated-dir> <vfs-path>",
+			Description: `Prints the embedded directory or file details.
+
+	Example:
+		aah vfs stat build/bin/aahwebsite /app/static/css/app.css`,
+			Action: vfsStatAction,
+		},
+		{
+			Name:      "diff",
+			Usage:     "Compares the embedded files against the working tree",
+			ArgsUsage: "<binary-or-generated-dir>",
+			Description: `Compares the embedded files against the working tree and reports modified,
+	added and deleted files. It reports the files filtered by 'build.excludes' and the
+	embedded files which match 'build.excludes' pattern, in case they are unexpected.
+	Exit status is 1 if differences found.
+
+	Physical paths are recorded relative to application base directory, it's the
+	source directory for application base or generated directory otherwise current
+	directory. Use '--base-dir' to compare with different application base directory.
+
+	Example:
+		aah vfs diff build/bin/aahwebsite
+		aah vfs diff /tmp/aahwebsite --base-dir /home/jeeva/aahwebsite`,
+			Flags: []console.Flag{
+				console.StringFlag{
+					Name:  "base-dir, d",
+					Usage: "Application base directory to compare with, default is inferred from the source",
+				},
+			},
+			Action: vfsDiffAction,
+		},
+	},
+}
+
+func vfsLsAction(c *console.Context) error {
+	img := loadVFSImageArg(c, "ls", 1)
+	prefix := strings.TrimSpace(c.String("mount"))
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, idx := range img.Indexes {
+		if !ess.IsStrEmpty(prefix) && !strings.HasPrefix(idx.Mount, prefix) && !strings.HasPrefix(prefix, idx.Mount) {
+			continue
+		}
+
+		var size, stored int64
+		for _, e := range idx.Files {
+			size += e.Size
+			stored += e.StoredSize
+		}
+		fmt.Printf("\nMount: %s <== %s\n", idx.Mount, idx.Physical)
+		fmt.Printf("Embed mode: %s, %d directories, %d files, size %s, stored %s\n\n",
+			idx.EmbedMode, len(idx.Dirs), len(idx.Files), humanSize(size), humanSize(stored))
+
+		for _, e := range vfsIndexEntries(idx) {
+			if !ess.IsStrEmpty(prefix) && !strings.HasPrefix(e.Path, prefix) {
+				continue
+			}
+			if e.dir {
+				_, _ = fmt.Fprintf(tw, "d\t-\t-\t-\t%s\t%s/\n", vfsTimeStr(e.Time), e.Path)
+				continue
+			}
+			_, _ = fmt.Fprintf(tw, "-\t%d\t%d\t%s\t%s\t%s\n", e.Size, e.StoredSize,
+				vfsEncodingStr(e.vfsIndexEntry), vfsTimeStr(e.Time), e.Path)
+		}
+		_ = tw.Flush()
+	}
+	fmt.Println()
+	return nil
+}
+
+func vfsCatAction(c *console.Context) error {
+	img := loadVFSImageArg(c, "cat", 2)
+	p := c.Args().Get(1)
+	_, e, dir := img.Find(p)
+	if e == nil || dir {
+		logFatalf("File '%s' not found in VFS", p)
+	}
+
+	var (
+		b   []byte
+		err error
+	)
+	if c.Bool("raw") {
+		b, err = img.StoredBytes(e)
+	} else {
+		b, err = img.Content(e)
+	}
+	if err != nil {
+		logFatal(err)
+	}
+	_, _ = os.Stdout.Write(b)
+	return nil
+}
+
+func vfsStatAction(c *console.Context) error {
+	img := loadVFSImageArg(c, "stat", 2)
+	p := c.Args().Get(1)
+	idx, e, dir := img.Find(p)
+	if e == nil {
+		logFatalf("'%s' not found in VFS", p)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "Path:\t%s\n", e.Path)
+	_, _ = fmt.Fprintf(tw, "Mount:\t%s <== %s\n", idx.Mount, idx.Physical)
+	_, _ = fmt.Fprintf(tw, "Physical:\t%s\n", vfsPhysicalPath(idx, idx.Physical, e.Path))
+	if dir {
+		_, _ = fmt.Fprintf(tw, "Type:\tdirectory\n")
+		_, _ = fmt.Fprintf(tw, "Mod Time:\t%s\n", vfsTimeStr(e.Time))
+		return tw.Flush()
+	}
+	_, _ = fmt.Fprintf(tw, "Type:\tfile\n")
+	_, _ = fmt.Fprintf(tw, "Size:\t%d (%s)\n", e.Size, humanSize(e.Size))
+	_, _ = fmt.Fprintf(tw, "Stored Size:\t%d (%s)\n", e.StoredSize, humanSize(e.StoredSize))
+	_, _ = fmt.Fprintf(tw, "Compression:\t%s\n", vfsEncodingStr(e))
+	if !ess.IsStrEmpty(e.Origin) {
+		_, _ = fmt.Fprintf(tw, "Origin:\t%s\n", e.Origin)
+	}
+	_, _ = fmt.Fprintf(tw, "Mod Time:\t%s\n", vfsTimeStr(e.Time))
+	_, _ = fmt.Fprintf(tw, "SHA-256:\t%s\n", e.SHA256)
+	_, _ = fmt.Fprintf(tw, "Stored SHA-256:\t%s\n", e.StoredSHA256)
+	_, _ = fmt.Fprintf(tw, "Embed Mode:\t%s\n", idx.EmbedMode)
+	return tw.Flush()
+}
+
+func vfsDiffAction(c *console.Context) error {
+	img := loadVFSImageArg(c, "diff", 1)
+	baseDir := absPath(strings.TrimSpace(c.String("base-dir")))
+	if ess.IsStrEmpty(baseDir) {
+		baseDir = vfsAppBaseDir(img.Source)
+	}
+
+	changes := 0
+	for _, idx := range img.Indexes {
+		physical := filepath.FromSlash(idx.Physical)
+		if !filepath.IsAbs(physical) {
+			physical = filepath.Join(baseDir, physical)
+		}
+		fmt.Printf("\nMount: %s <== %s\n", idx.Mount, physical)
+		if !ess.IsFileExists(physical) {
+			fmt.Printf("  physical path does not exists, use '--base-dir'\n")
+			changes++
+			continue
+		}
+		changes += diffVFSMount(idx, physical)
+	}
+	fmt.Println()
+
+	if changes > 0 {
+		cliLog.Warnf("%d differences found", changes)
+		exit(1)
+	}
+	cliLog.Infof("No differences found")
+	return nil
+}
+
+// diffVFSMount method compares the mount files with working tree and prints
+// the report. It returns the count of differences.
+func diffVFSMount(idx *vfsIndex, physical string) int {
+	embedded := make(map[string]*vfsIndexEntry)
+	for _, e := range idx.Files {
+		if e.Origin != vfsOriginVariant {
+			embedded[e.Path] = e
+		}
+	}
+	embeddedDirs := make(map[string]bool)
+	for _, e := range idx.Dirs {
+		embeddedDirs[e.Path] = true
+	}
+
+	var modified, added, deleted, filtered, kept, assets []string
+	_ = filepath.Walk(physical, func(fpath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(physical, fpath)
+		mp := path.Join(idx.Mount, filepath.ToSlash(rel))
+		if info.IsDir() {
+			if fpath != physical && !embeddedDirs[mp] {
+				if pattern := idx.MatchExclude(mp); !ess.IsStrEmpty(pattern) {
+					filtered = append(filtered, fmt.Sprintf("%s/ (matches '%s')", mp, pattern))
+				} else {
+					added = append(added, mp+"/")
+				}
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		e, found := embedded[mp]
+		if !found {
+			if pattern := idx.MatchExclude(mp); !ess.IsStrEmpty(pattern) {
+				filtered = append(filtered, fmt.Sprintf("%s (matches '%s')", mp, pattern))
+			} else {
+				added = append(added, mp)
+			}
+			return nil
+		}
+		delete(embedded, mp)
+
+		if e.Origin == vfsOriginAsset {
+			assets = append(assets, mp)
+		} else if sum, err := pathChecksum(fpath); err != nil || sum != e.SHA256 {
+			modified = append(modified, mp)
+		}
+		return nil
+	})
+
+	for mp, e := range embedded {
+		if e.Origin == vfsOriginAsset {
+			assets = append(assets, mp)
+		} else {
+			deleted = append(deleted, mp)
+		}
+	}
+	for _, e := range idx.Files {
+		if pattern := idx.MatchExclude(e.Path); !ess.IsStrEmpty(pattern) {
+			kept = append(kept, fmt.Sprintf("%s (matches '%s')", e.Path, pattern))
+		}
+	}
+
+	printVFSDiff("M", "modified", modified)
+	printVFSDiff("A", "not embedded, added after build", added)
+	printVFSDiff("D", "embedded, deleted from working tree", deleted)
+	printVFSDiff("X", "filtered by build.excludes", filtered)
+	printVFSDiff("K", "kept, but matches build.excludes", kept)
+	if len(assets) > 0 {
+		fmt.Printf("  %d asset pipeline output files are not compared\n", len(assets))
+	}
+	if len(modified)+len(added)+len(deleted) == 0 {
+		fmt.Printf("  embedded files are up-to-date with working tree\n")
+	}
+	return len(modified) + len(added) + len(deleted)
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Unexported methods
+//___________________________________
+
+type vfsListEntry struct {
+	*vfsIndexEntry
+	dir bool
+}
+
+// vfsIndexEntries method returns the directories and files of the mount
+// sorted by path.
+func vfsIndexEntries(idx *vfsIndex) []*vfsListEntry {
+	var entries []*vfsListEntry
+	for _, e := range idx.Dirs {
+		entries = append(entries, &vfsListEntry{vfsIndexEntry: e, dir: true})
+	}
+	for _, e := range idx.Files {
+		entries = append(entries, &vfsListEntry{vfsIndexEntry: e})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries
+}
+
+func loadVFSImageArg(c *console.Context, cmdName string, nargs int) *vfsImage {
+	if c.NArg() < nargs {
+		_ = console.ShowCommandHelp(c, cmdName)
+		exit(1)
+	}
+	img, err := loadVFSImage(absPath(c.Args().First()))
+	if err != nil {
+		logFatal(err)
+	}
+	return img
+}
+
+// vfsAppBaseDir method returns the application base directory of given VFS
+// image source. For binary it's current directory.
+func vfsAppBaseDir(src string) string {
+	if !ess.IsDir(src) {
+		cwd, err := os.Getwd()
+		if err != nil {
+			logFatal(err)
+		}
+		return cwd
+	}
+	if filepath.Base(src) == "generated" && filepath.Base(filepath.Dir(src)) == "app" {
+		return filepath.Dir(filepath.Dir(src))
+	}
+	return src
+}
+
+func vfsPhysicalPath(idx *vfsIndex, physical, mp string) string {
+	return filepath.Join(physical, filepath.FromSlash(strings.TrimPrefix(mp, idx.Mount)))
+}
+
+func vfsEncodingStr(e *vfsIndexEntry) string {
+	if ess.IsStrEmpty(e.Encoding) {
+		return "none"
+	}
+	return e.Encoding
+}
+
+func vfsTimeStr(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func printVFSDiff(marker, title string, paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+	sort.Strings(paths)
+	fmt.Printf("  %s (%d):\n", title, len(paths))
+	for _, p := range paths {
+		fmt.Printf("    %s %s\n", marker, p)
+	}
+}
+
+func humanSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
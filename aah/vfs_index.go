@@ -0,0 +1,280 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"aahframe.work/essentials"
+	"github.com/andybalholm/brotli"
+)
+
+const (
+	vfsIndexMarker  = `{"aah_vfs_index":`
+	vfsIndexHeadLen = 32
+
+	vfsOriginAsset   = "asset"
+	vfsOriginVariant = "variant"
+)
+
+// vfsIndexEnabled is true if mount index is generated on single binary build,
+// it's configured via 'vfs.index' in 'aah.project'.
+var vfsIndexEnabled = true
+
+// vfsIndex struct is the index of embedded mount, it's generated as Go string
+// variable next to the mount on single binary build and not added into VFS.
+// It's used by 'aah vfs' command to inspect the embedded files of binary or
+// generated directory. Physical path is relative to application base
+// directory.
+type vfsIndex struct {
+	Version   int              `json:"aah_vfs_index"`
+	Mount     string           `json:"mount"`
+	Physical  string           `json:"physical"`
+	EmbedMode string           `json:"embed_mode"`
+	Excludes  []string         `json:"excludes,omitempty"`
+	NoGzip    []string         `json:"no_gzip,omitempty"`
+	Dirs      []*vfsIndexEntry `json:"dirs"`
+	Files     []*vfsIndexEntry `json:"files"`
+}
+
+// vfsIndexEntry struct holds the embedded directory or file details. Stored
+// checksum and head bytes are used to locate the file bytes within binary.
+type vfsIndexEntry struct {
+	Path         string    `json:"path"`
+	Time         time.Time `json:"time"`
+	Size         int64     `json:"size,omitempty"`
+	StoredSize   int64     `json:"stored_size,omitempty"`
+	Encoding     string    `json:"encoding,omitempty"`
+	Origin       string    `json:"origin,omitempty"`
+	SHA256       string    `json:"sha256,omitempty"`
+	StoredSHA256 string    `json:"stored_sha256,omitempty"`
+	Head         []byte    `json:"head,omitempty"`
+}
+
+// vfsStoredWriter struct computes the stored bytes details of file while
+// writing it into VFS source or data blob.
+type vfsStoredWriter struct {
+	w    io.Writer
+	h    hash.Hash
+	n    int64
+	head []byte
+}
+
+func newVFSStoredWriter(w io.Writer) *vfsStoredWriter {
+	return &vfsStoredWriter{w: w, h: sha256.New()}
+}
+
+func (sw *vfsStoredWriter) Write(p []byte) (int, error) {
+	if l := vfsIndexHeadLen - len(sw.head); l > 0 {
+		if l > len(p) {
+			l = len(p)
+		}
+		sw.head = append(sw.head, p[:l]...)
+	}
+	_, _ = sw.h.Write(p)
+	sw.n += int64(len(p))
+	return sw.w.Write(p)
+}
+
+// Update method updates the stored details into index entry.
+func (sw *vfsStoredWriter) Update(e *vfsIndexEntry) {
+	e.StoredSize = sw.n
+	e.StoredSHA256 = hex.EncodeToString(sw.h.Sum(nil))
+	e.Head = sw.head
+	if ess.IsStrEmpty(e.Encoding) && e.StoredSHA256 != e.SHA256 {
+		e.Encoding = "gzip"
+	}
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// VFS image methods
+//___________________________________
+
+// vfsImage struct holds the bytes of binary or generated VFS sources, which
+// has the embedded file bytes and VFS indexes.
+type vfsImage struct {
+	Source  string
+	data    []byte
+	Indexes []*vfsIndex
+}
+
+// loadVFSImage method loads the VFS image from aah application binary or
+// generated directory. For generated directory the string literals of VFS
+// sources and data blobs are taken.
+func loadVFSImage(src string) (*vfsImage, error) {
+	fi, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+
+	img := &vfsImage{Source: src}
+	if fi.IsDir() {
+		if img.data, err = readGeneratedVFS(src); err != nil {
+			return nil, err
+		}
+	} else if img.data, err = ioutil.ReadFile(src); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for pos := 0; ; {
+		i := bytes.Index(img.data[pos:], []byte(vfsIndexMarker))
+		if i == -1 {
+			break
+		}
+		pos += i
+		idx := &vfsIndex{}
+		if err = json.NewDecoder(bytes.NewReader(img.data[pos:])).Decode(idx); err == nil && !seen[idx.Mount] {
+			seen[idx.Mount] = true
+			img.Indexes = append(img.Indexes, idx)
+		}
+		pos += len(vfsIndexMarker)
+	}
+
+	if len(img.Indexes) == 0 {
+		return nil, fmt.Errorf("No VFS index found in '%s', it requires single binary built by aah CLI v%s or above with 'vfs.index' enabled", src, Version)
+	}
+	sort.Slice(img.Indexes, func(i, j int) bool { return img.Indexes[i].Mount < img.Indexes[j].Mount })
+	return img, nil
+}
+
+func readGeneratedVFS(dir string) ([]byte, error) {
+	if genDir := filepath.Join(dir, "app", "generated"); ess.IsFileExists(genDir) {
+		dir = genDir
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "aah*_vfs.go"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("No VFS sources found in '%s'", dir)
+	}
+
+	buf := &bytes.Buffer{}
+	for _, f := range files {
+		file, err := parser.ParseFile(token.NewFileSet(), f, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				if s, err := strconv.Unquote(lit.Value); err == nil {
+					buf.WriteString(s)
+				}
+			}
+			return true
+		})
+
+		if blobFile := vfsBlobFile(f); ess.IsFileExists(blobFile) {
+			b, err := ioutil.ReadFile(blobFile)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(b)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// Find method returns the mount index and entry of given VFS path.
+func (img *vfsImage) Find(p string) (*vfsIndex, *vfsIndexEntry, bool) {
+	p = path.Clean("/" + p)
+	for _, idx := range img.Indexes {
+		for _, e := range idx.Files {
+			if e.Path == p {
+				return idx, e, false
+			}
+		}
+		for _, e := range idx.Dirs {
+			if e.Path == p {
+				return idx, e, true
+			}
+		}
+	}
+	return nil, nil, false
+}
+
+// StoredBytes method locates the stored bytes of file entry within image by
+// its head bytes and verifies it with stored checksum.
+func (img *vfsImage) StoredBytes(e *vfsIndexEntry) ([]byte, error) {
+	if e.StoredSize <= int64(len(e.Head)) {
+		// small files are held by the index itself, compiler may not keep
+		// its bytes contiguous within binary
+		return e.Head[:e.StoredSize], nil
+	}
+	for pos := 0; pos < len(img.data); {
+		i := bytes.Index(img.data[pos:], e.Head)
+		if i == -1 {
+			break
+		}
+		pos += i
+		if end := int64(pos) + e.StoredSize; end <= int64(len(img.data)) {
+			b := img.data[pos:end]
+			if sum := sha256.Sum256(b); hex.EncodeToString(sum[:]) == e.StoredSHA256 {
+				return b, nil
+			}
+		}
+		pos++
+	}
+	return nil, fmt.Errorf("Unable to locate content of '%s' in '%s'", e.Path, img.Source)
+}
+
+// Content method returns the file content, stored bytes are decompressed.
+func (img *vfsImage) Content(e *vfsIndexEntry) ([]byte, error) {
+	b, err := img.StoredBytes(e)
+	if err != nil {
+		return nil, err
+	}
+
+	var r io.Reader
+	switch e.Encoding {
+	case "gzip":
+		gr, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		r = gr
+	case vfsEncodingBrotli:
+		r = brotli.NewReader(bytes.NewReader(b))
+	default:
+		return b, nil
+	}
+	return ioutil.ReadAll(r)
+}
+
+// MatchExclude method returns the exclude pattern matching any element of
+// the mount path, embed walk skips the directory or file by its name.
+func (idx *vfsIndex) MatchExclude(mp string) string {
+	rel := strings.Trim(strings.TrimPrefix(mp, idx.Mount), "/")
+	if ess.IsStrEmpty(rel) {
+		return ""
+	}
+	for _, name := range strings.Split(rel, "/") {
+		for _, pattern := range idx.Excludes {
+			if ok, _ := filepath.Match(pattern, name); ok {
+				return pattern
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,162 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"aahframe.work/essentials"
+)
+
+func TestVFSStoredWriter(t *testing.T) {
+	content := []byte(strings.Repeat("aah vfs stored writer ", 10))
+	sum := sha256.Sum256(content)
+
+	buf := &bytes.Buffer{}
+	sw := newVFSStoredWriter(buf)
+	for _, chunk := range [][]byte{content[:3], content[3:50], content[50:]} {
+		if _, err := sw.Write(chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	e := &vfsIndexEntry{SHA256: hex.EncodeToString(sum[:])}
+	sw.Update(e)
+	if !bytes.Equal(buf.Bytes(), content) {
+		t.Errorf("expected content written as-is, got %q", buf.Bytes())
+	}
+	if e.StoredSize != int64(len(content)) || e.StoredSHA256 != e.SHA256 {
+		t.Errorf("unexpected stored details: size %d, sha256 %s", e.StoredSize, e.StoredSHA256)
+	}
+	if !bytes.Equal(e.Head, content[:vfsIndexHeadLen]) {
+		t.Errorf("expected head %q, got %q", content[:vfsIndexHeadLen], e.Head)
+	}
+	if e.Encoding != "" {
+		t.Errorf("expected no encoding, got %s", e.Encoding)
+	}
+
+	// stored bytes differ from content, it's gzipped
+	e = &vfsIndexEntry{SHA256: "content-sha256"}
+	sw.Update(e)
+	if e.Encoding != "gzip" {
+		t.Errorf("expected gzip encoding, got %s", e.Encoding)
+	}
+}
+
+// TestVFSImageRoundTrip generates the VFS source of a mount, reads it back
+// via loadVFSImage and compares the mount with working tree.
+func TestVFSImageRoundTrip(t *testing.T) {
+	files := map[string]string{
+		"css/app.css": strings.Repeat("body { margin: 0; padding: 0; }\n", 100),
+		"js/app.js":   "var a = 1;\n",
+		"robots.txt":  "User-agent: *\n",
+		"old.bak":     "excluded",
+	}
+
+	defer func() { vfsPrecompressPolicy, vfsVariants = nil, nil }()
+	for _, embedMode := range []string{vfsEmbedModeString, vfsEmbedModeGoEmbed} {
+		t.Run(embedMode, func(t *testing.T) {
+			baseDir, err := ioutil.TempDir("", "aahvfs")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = os.RemoveAll(baseDir) }()
+
+			staticDir := filepath.Join(baseDir, "static")
+			for name, content := range files {
+				fpath := filepath.Join(staticDir, filepath.FromSlash(name))
+				if err = ess.MkDirAll(filepath.Dir(fpath), permRWXRXRX); err != nil {
+					t.Fatal(err)
+				}
+				if err = ioutil.WriteFile(fpath, []byte(content), permRWRWRW); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			vfsPrecompressPolicy = &vfsPrecompress{
+				encodings:   []string{vfsEncodingBrotli},
+				extensions:  map[string]bool{"css": true},
+				policy:      make(map[string][]string),
+				brotliLevel: 5,
+			}
+			vfsVariants = nil
+			if err = processMount(true, baseDir, "/static", staticDir, ess.Excludes{"*.bak"}, nil, embedMode); err != nil {
+				t.Fatal(err)
+			}
+
+			img, err := loadVFSImage(baseDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(img.Indexes) != 1 {
+				t.Fatalf("expected 1 mount index, got %d", len(img.Indexes))
+			}
+			idx := img.Indexes[0]
+			if idx.Mount != "/static" || idx.Physical != "static" || idx.EmbedMode != embedMode {
+				t.Errorf("unexpected mount index: %s <== %s [%s]", idx.Mount, idx.Physical, idx.EmbedMode)
+			}
+
+			for name, content := range files {
+				_, e, dir := img.Find("/static/" + name)
+				if name == "old.bak" {
+					if e != nil {
+						t.Errorf("%s: expected to be excluded", name)
+					}
+					continue
+				}
+				if e == nil || dir {
+					t.Fatalf("%s: not found", name)
+				}
+				b, err := img.Content(e)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(b) != content {
+					t.Errorf("%s: content mismatch, got %q", name, b)
+				}
+			}
+
+			if _, e, _ := img.Find("/static/css/app.css"); e.Encoding != "gzip" {
+				t.Errorf("expected gzip encoding, got '%s'", e.Encoding)
+			}
+			_, e, _ := img.Find("/static/css/app.css.br")
+			if e == nil || e.Encoding != vfsEncodingBrotli || e.Origin != vfsOriginVariant {
+				t.Fatalf("expected brotli variant of app.css, got %#v", e)
+			}
+			if b, err := img.Content(e); err != nil || string(b) != files["css/app.css"] {
+				t.Errorf("brotli variant content mismatch: %v", err)
+			}
+			if _, _, dir := img.Find("/static/css"); !dir {
+				t.Errorf("expected directory '/static/css'")
+			}
+			if encodings := vfsVariants["/static/css/app.css"]; len(encodings) != 1 || encodings[0] != vfsEncodingBrotli {
+				t.Errorf("expected variant lookup of app.css, got %v", vfsVariants)
+			}
+
+			if n := diffVFSMount(idx, staticDir); n != 0 {
+				t.Errorf("expected no differences, got %d", n)
+			}
+			if err = ioutil.WriteFile(filepath.Join(staticDir, "js", "app.js"), []byte("var a = 2;\n"), permRWRWRW); err != nil {
+				t.Fatal(err)
+			}
+			if err = ioutil.WriteFile(filepath.Join(staticDir, "humans.txt"), []byte("aah\n"), permRWRWRW); err != nil {
+				t.Fatal(err)
+			}
+			if err = os.Remove(filepath.Join(staticDir, "robots.txt")); err != nil {
+				t.Fatal(err)
+			}
+			if n := diffVFSMount(idx, staticDir); n != 3 {
+				t.Errorf("expected 3 differences (modified, added, deleted), got %d", n)
+			}
+		})
+	}
+}
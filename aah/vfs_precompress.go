@@ -0,0 +1,349 @@
+// Copyright (c) Jeevanandam M. (https://github.com/jeevatkm)
+// Source code and usage is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"aahframe.work/config"
+	"aahframe.work/essentials"
+	"github.com/andybalholm/brotli"
+)
+
+// Precompressed variant encodings, variant is added into VFS next to the
+// file with encoding extension. For e.g.: '/static/css/app.css.br'.
+const (
+	vfsEncodingBrotli = "br"
+)
+
+var vfsEncodingExts = map[string]string{
+	vfsEncodingBrotli: ".br",
+}
+
+var defaultPrecompressExts = []string{"html", "htm", "css", "js", "mjs", "json",
+	"map", "svg", "xml", "txt", "md", "csv", "wasm", "ico", "ttf", "otf", "eot"}
+
+// vfsPrecompressPolicy holds the precompress policy of current build, it's
+// nil if precompression is not configured via 'vfs.precompress' in
+// 'aah.project'.
+var vfsPrecompressPolicy *vfsPrecompress
+
+// vfsVariants holds the precompressed variant encodings of embedded files by
+// VFS path of current build. It's generated as lookup package for the
+// application, so precompressed variant can be picked by 'Accept-Encoding'.
+var vfsVariants map[string][]string
+
+// vfsPrecompress struct holds the precompressed variant settings of VFS
+// embed. Default encodings are applied for files having one of the
+// extensions, 'policy' overrides encodings by file extension.
+type vfsPrecompress struct {
+	encodings   []string
+	minSize     int64
+	extensions  map[string]bool
+	policy      map[string][]string
+	brotliLevel int
+}
+
+// vfsVariant struct holds the precompressed bytes of a file for encoding.
+type vfsVariant struct {
+	Encoding string
+	Ext      string
+	Data     []byte
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// VFS precompress methods
+//___________________________________
+
+// newVFSPrecompress method returns the precompress policy from 'aah.project'.
+//
+//	vfs {
+//	  precompress {
+//	    encodings = ["br"]
+//	    min_size = 1400
+//	    extensions = ["html", "css", "js", "json", "svg"]
+//	    brotli_level = 11
+//	    policy {
+//	      wasm = ["br"]
+//	      txt = []
+//	    }
+//	  }
+//	}
+//
+// Returns nil if encodings and policy are not configured.
+func newVFSPrecompress(projectCfg *config.Config) (*vfsPrecompress, error) {
+	encodings, _ := projectCfg.StringList("vfs.precompress.encodings")
+	policyKeys := projectCfg.KeysByPath("vfs.precompress.policy")
+	if len(encodings) == 0 && len(policyKeys) == 0 {
+		return nil, nil
+	}
+
+	p := &vfsPrecompress{
+		minSize:     int64(projectCfg.IntDefault("vfs.precompress.min_size", int(defaultGzipMinSize))),
+		extensions:  make(map[string]bool),
+		policy:      make(map[string][]string),
+		brotliLevel: projectCfg.IntDefault("vfs.precompress.brotli_level", brotli.BestCompression),
+	}
+	if p.brotliLevel < brotli.BestSpeed || p.brotliLevel > brotli.BestCompression {
+		return nil, fmt.Errorf("vfs.precompress.brotli_level must be between %d and %d",
+			brotli.BestSpeed, brotli.BestCompression)
+	}
+
+	var err error
+	if p.encodings, err = validateVFSEncodings(encodings); err != nil {
+		return nil, err
+	}
+
+	exts, found := projectCfg.StringList("vfs.precompress.extensions")
+	if !found {
+		exts = defaultPrecompressExts
+	}
+	for _, ext := range exts {
+		p.extensions[normalizeExt(ext)] = true
+	}
+
+	for _, key := range policyKeys {
+		values, _ := projectCfg.StringList("vfs.precompress.policy." + key)
+		if p.policy[normalizeExt(key)], err = validateVFSEncodings(values); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// Encodings method returns the precompress encodings applicable for the file.
+func (p *vfsPrecompress) Encodings(name string, size int64) []string {
+	if size < p.minSize {
+		return nil
+	}
+	ext := normalizeExt(filepath.Ext(name))
+	if encodings, found := p.policy[ext]; found {
+		return encodings
+	}
+	if p.extensions[ext] {
+		return p.encodings
+	}
+	return nil
+}
+
+// Variants method returns the precompressed variants of the file, variant
+// is dropped if compression has no benefit. Reader is read from beginning.
+func (p *vfsPrecompress) Variants(r io.ReadSeeker, fi os.FileInfo) ([]*vfsVariant, error) {
+	var variants []*vfsVariant
+	for _, encoding := range p.Encodings(fi.Name(), fi.Size()) {
+		if _, err := r.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		b, err := p.compress(encoding, r)
+		if err != nil {
+			return nil, err
+		}
+		if b == nil {
+			continue
+		}
+		variants = append(variants, &vfsVariant{
+			Encoding: encoding,
+			Ext:      vfsEncodingExts[encoding],
+			Data:     b,
+		})
+	}
+	return variants, nil
+}
+
+// String method returns the policy settings in stable order, it's part of
+// the VFS mount fingerprint.
+func (p *vfsPrecompress) String() string {
+	var exts, policy []string
+	for ext := range p.extensions {
+		exts = append(exts, ext)
+	}
+	for ext, encodings := range p.policy {
+		policy = append(policy, ext+"="+strings.Join(encodings, "+"))
+	}
+	sort.Strings(exts)
+	sort.Strings(policy)
+	return fmt.Sprintf("encodings:%s minsize:%d exts:%s policy:%s br:%d",
+		strings.Join(p.encodings, ","), p.minSize, strings.Join(exts, ","),
+		strings.Join(policy, ","), p.brotliLevel)
+}
+
+// compress method returns the compressed bytes of reader content for
+// encoding, it returns nil if compressed size is not smaller than content.
+func (p *vfsPrecompress) compress(encoding string, r io.ReadSeeker) ([]byte, error) {
+	var sum, objExt string
+	if vfsCacheStore != nil {
+		var err error
+		if sum, err = contentSum(r); err != nil {
+			return nil, err
+		}
+		objExt = p.objectExt(encoding)
+		if b, found := vfsCacheStore.VariantBytes(sum, objExt); found {
+			return b, nil
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	w := brotli.NewWriterLevel(buf, p.brotliLevel)
+
+	size, err := io.Copy(w, r)
+	if err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+
+	var b []byte
+	if int64(buf.Len()) < size {
+		b = buf.Bytes()
+	}
+	if vfsCacheStore != nil {
+		vfsCacheStore.SaveVariantBytes(sum, objExt, b)
+	}
+	return b, nil
+}
+
+// objectExt method returns the cache object extension of encoding, level is
+// part of it since variant bytes differ by compression level.
+func (p *vfsPrecompress) objectExt(encoding string) string {
+	return fmt.Sprintf("%s-%d", vfsEncodingExts[encoding], p.brotliLevel)
+}
+
+func validateVFSEncodings(encodings []string) ([]string, error) {
+	var result []string
+	for _, encoding := range encodings {
+		encoding = strings.ToLower(strings.TrimSpace(encoding))
+		if ess.IsStrEmpty(encoding) {
+			continue
+		}
+		if _, found := vfsEncodingExts[encoding]; !found {
+			return nil, fmt.Errorf("Unsupported vfs.precompress encoding '%s', supported encoding is '%s'",
+				encoding, vfsEncodingBrotli)
+		}
+		result = append(result, encoding)
+	}
+	return result, nil
+}
+
+func normalizeExt(ext string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))
+}
+
+// isVFSPrecompressConfigured method returns true if 'vfs.precompress' has
+// encodings or policy in 'aah.project'.
+func isVFSPrecompressConfigured(projectCfg *config.Config) bool {
+	encodings, _ := projectCfg.StringList("vfs.precompress.encodings")
+	return len(encodings) > 0 || len(projectCfg.KeysByPath("vfs.precompress.policy")) > 0
+}
+
+func addVFSVariants(variants map[string][]string) {
+	if vfsVariants == nil {
+		vfsVariants = make(map[string][]string)
+	}
+	for p, encodings := range variants {
+		vfsVariants[p] = encodings
+	}
+}
+
+// generateVFSVariantsSource method generates the precompressed variant lookup
+// package 'app/generated/precompressed' if 'vfs.precompress' is configured.
+// It's generated on non-single binary build and 'aah run' too with empty
+// lookup, so application code which imports it compiles always.
+func generateVFSVariantsSource(appBaseDir string, projectCfg *config.Config) error {
+	if !isVFSPrecompressConfigured(projectCfg) {
+		return nil
+	}
+
+	paths := make([]string, 0, len(vfsVariants))
+	for p := range vfsVariants {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return generateSource(filepath.Join(appBaseDir, "app", "generated", "precompressed"), "aah_precompressed.go",
+		aahPrecompressedTemplate, map[string]interface{}{
+			"AahVersion":   strings.TrimPrefix(strings.TrimSpace(aahVer), "v"),
+			"Variants":     vfsVariants,
+			"Paths":        paths,
+			"EncodingExts": vfsEncodingExts,
+		})
+}
+
+const aahPrecompressedTemplate = `// Code generated by aah CLI, DO NOT EDIT
+//
+// aah framework v{{ .AahVersion }} - https://aahframework.org
+// FILE: aah_precompressed.go
+// DESC: aah application precompressed VFS variants lookup by Accept-Encoding
+
+// Package precompressed provides the precompressed variants of files embedded
+// in VFS. aah static file handler does not negotiate them, serve it from
+// application, for e.g.:
+//
+//	if vpath, enc := precompressed.Lookup(vfsPath, ctx.Req.Header.Get("Accept-Encoding")); vpath != "" {
+//		// serve bytes of 'vpath' from aah.App().VFS() with content type of 'vfsPath'
+//		ctx.Res.Header().Set("Content-Encoding", enc)
+//		ctx.Res.Header().Add("Vary", "Accept-Encoding")
+//	}
+package precompressed
+
+import (
+	"strconv"
+	"strings"
+)
+
+var variants = map[string][]string{ {{- range $p := .Paths }}
+	{{ printf "%q" $p }}: { {{- range $i, $e := index $.Variants $p }}{{ if $i }}, {{ end }}{{ printf "%q" $e }}{{ end -}} },{{ end }}
+}
+
+var encodingExts = map[string]string{ {{- range $e, $ext := .EncodingExts }}
+	{{ printf "%q" $e }}: {{ printf "%q" $ext }},{{ end }}
+}
+
+// Encodings method returns the precompressed variant encodings of VFS path.
+func Encodings(vfsPath string) []string {
+	return variants[vfsPath]
+}
+
+// Lookup method returns the VFS path of precompressed variant and its encoding
+// which is acceptable for the request 'Accept-Encoding' header value. It
+// returns empty values if VFS path has no acceptable variant.
+func Lookup(vfsPath, acceptEncoding string) (string, string) {
+	for _, enc := range variants[vfsPath] {
+		if accepts(acceptEncoding, enc) {
+			return vfsPath + encodingExts[enc], enc
+		}
+	}
+	return "", ""
+}
+
+func accepts(acceptEncoding, enc string) bool {
+	wildcard := false
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		if name != enc && name != "*" {
+			continue
+		}
+		ok := true
+		for _, f := range fields[1:] {
+			if f = strings.TrimSpace(f); strings.HasPrefix(f, "q=") {
+				q, err := strconv.ParseFloat(f[2:], 64)
+				ok = err == nil && q > 0
+			}
+		}
+		if name == enc {
+			return ok
+		}
+		wildcard = ok
+	}
+	return wildcard
+}
+`